@@ -56,8 +56,9 @@ func runPVM(t *testing.T, args ...string) (string, int) {
 
 // releaseCacheEntry mirrors config.ReleaseCache for JSON serialisation.
 type releaseCacheEntry struct {
-	Versions  []string  `json:"versions"`
-	Timestamp time.Time `json:"timestamp"`
+	Versions   []string  `json:"versions"`
+	Timestamp  time.Time `json:"timestamp"`
+	SourceKind string    `json:"source_kind"`
 }
 
 // primeCache writes a releases.cache file into pvmHome so the binary never
@@ -65,8 +66,9 @@ type releaseCacheEntry struct {
 func primeCache(t *testing.T, pvmHome string, versions []string) {
 	t.Helper()
 	cache := releaseCacheEntry{
-		Versions:  versions,
-		Timestamp: time.Now(),
+		Versions:   versions,
+		Timestamp:  time.Now(),
+		SourceKind: "github",
 	}
 	data, err := json.Marshal(cache)
 	if err != nil {
@@ -265,6 +267,36 @@ func TestCLISubcommandHelp(t *testing.T) {
 	}
 }
 
+// ── Shell completion ──────────────────────────────────────────────────────────
+
+func TestCLICompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		out, code := runPVM(t, "completion", shell)
+		if code != 0 {
+			t.Errorf("pvm completion %s: expected exit 0, got %d\noutput: %s", shell, code, out)
+			continue
+		}
+		if !strings.Contains(out, "__complete") {
+			t.Errorf("pvm completion %s: expected dynamic completion script, got:\n%s", shell, out)
+		}
+	}
+}
+
+func TestCLICompleteInstallUsesCache(t *testing.T) {
+	pvmHome := t.TempDir()
+	primeCache(t, pvmHome, []string{"3.78.1", "3.78.0"})
+
+	out, code := runPVMInDir(pvmHome, "__complete", "install", "3.78")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d\noutput: %s", code, out)
+	}
+	for _, v := range []string{"3.78.1", "3.78.0"} {
+		if !strings.Contains(out, v) {
+			t.Errorf("expected %s in completions, got:\n%s", v, out)
+		}
+	}
+}
+
 func TestCLINoColorFlag(t *testing.T) {
 	out, code := runPVM(t, "--no-color", "current")
 	if code != 0 {
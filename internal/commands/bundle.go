@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create and import offline release bundles",
+	Long: `Move releases to machines without network access.
+
+'pvm bundle create' packages release archives for the given platforms, their
+SHA-256 checksums and release metadata into a single tar file. Copy it over
+and run 'pvm bundle import' to install the archives for that machine's
+platform, verified against the bundled checksums.`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create <version>...",
+	Short: "Package releases into a bundle",
+	Long: `Package the release archives of one or more versions into a bundle. Other
+tools are bundled with a tool prefix, e.g. 'esc@0.9.1'.
+
+Examples:
+  pvm bundle create 3.78.1 3.90.0 --platforms linux/amd64,linux/arm64 -o toolchain.tar
+  pvm bundle create latest esc@latest -o toolchain.tar`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeAvailableVersions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		platforms, _ := cmd.Flags().GetStringSlice("platforms")
+		if len(platforms) == 0 {
+			goos, arch := config.GetPlatformInfo()
+			platforms = []string{goos + "/" + arch}
+		}
+
+		stage, err := os.MkdirTemp("", "pvm-bundle-*")
+		if err != nil {
+			return fmt.Errorf("failed to create staging directory: %v", err)
+		}
+		defer os.RemoveAll(stage)
+
+		for _, spec := range args {
+			tool, version, err := config.ParseToolVersion(spec)
+			if err != nil {
+				return err
+			}
+			resolvedVersion, err := newManager(tool).AddToBundle(cmd.Context(), stage, version, platforms)
+			if err != nil {
+				return fmt.Errorf("failed to bundle %s %s: %w", tool.DisplayName, version, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Info("Bundled "+tool.DisplayName), resolvedVersion)
+		}
+
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", output, err)
+		}
+		if err := utils.CreateTar(f, stage); err != nil {
+			f.Close()
+			os.Remove(output)
+			return fmt.Errorf("failed to write %s: %v", output, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %v", output, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s (%s)\n", utils.Success("Created bundle"), output, strings.Join(platforms, ", "))
+		return nil
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Install the releases in a bundle",
+	Long: `Install the releases in a bundle created with 'pvm bundle create' for this
+machine's platform, or the one given with --os and --arch. No network access
+is needed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		platformOpts, err := platformOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		dir, err := os.MkdirTemp("", "pvm-bundle-*")
+		if err != nil {
+			return fmt.Errorf("failed to create staging directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		if err := utils.ExtractTar(cmd.Context(), args[0], dir); err != nil {
+			return fmt.Errorf("failed to read bundle %s: %w", args[0], err)
+		}
+
+		imported := 0
+		for _, tool := range config.Tools {
+			versions, err := newManager(tool, platformOpts...).ImportBundle(cmd.Context(), dir)
+			if err != nil {
+				return err
+			}
+			for _, version := range versions {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Installed "+tool.DisplayName), version)
+			}
+			imported += len(versions)
+		}
+		if imported == 0 {
+			return fmt.Errorf("%s does not contain any releases", args[0])
+		}
+		return nil
+	},
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringSlice("platforms", nil, "Platforms to bundle, as os/arch (default: this machine's)")
+	bundleCreateCmd.Flags().StringP("output", "o", "pvm-bundle.tar", "Path of the bundle to write")
+	addPlatformFlags(bundleImportCmd)
+
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleImportCmd)
+}
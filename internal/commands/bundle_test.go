@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestBundleCreateAndImport(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	useReleaseDir(t)
+
+	bundle := filepath.Join(t.TempDir(), "toolchain.tar")
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"bundle", "create", "3.78.1", "-o", bundle})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("bundle create: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Created bundle "+bundle) {
+		t.Errorf("expected a success message, got: %s", buf.String())
+	}
+
+	// Import into an empty PVM directory.
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"bundle", "import", bundle})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("bundle import: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Installed Pulumi 3.78.1") {
+		t.Errorf("expected an install message, got: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "versions", "3.78.1", "pulumi")); err != nil {
+		t.Errorf("expected 3.78.1 to be installed: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clear the release cache",
+	Long: `Inspect and clear the cached list of available releases.
+
+The release list is cached for a day. Set cache_ttl ('pvm config set
+cache_ttl 7d') or PVM_CACHE_TTL to a duration such as 12h or 7d, to 0 to
+refresh it on every use, or to never to only refresh it with
+'pvm list --all --refresh' or 'pvm cache clear'.`,
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the release cache of each tool",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tools, err := cacheTools(cmd)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		for i, tool := range tools {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintln(out, utils.Info(fmt.Sprintf("%s release cache:", tool.DisplayName)))
+
+			info, err := newManager(tool).CacheInfo()
+			if os.IsNotExist(err) {
+				fmt.Fprintf(out, "  Path:    %s\n", config.GetToolCachePath(tool))
+				fmt.Fprintln(out, "  Status:  not created yet")
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read the %s release cache: %v", tool.DisplayName, err)
+			}
+
+			age := time.Since(info.Timestamp).Round(time.Minute)
+			status := utils.Success("fresh")
+			if info.OtherSource {
+				status = utils.Warning("filled by another release source, refreshed on next use")
+			} else if info.Expired() {
+				status = utils.Warning("expired, refreshed on next use")
+			}
+			source := info.SourceKind
+			if source == "" {
+				source = "unknown"
+			} else if info.SourceLocation != "" {
+				source += " (" + info.SourceLocation + ")"
+			}
+			fmt.Fprintf(out, "  Path:    %s\n", info.Path)
+			fmt.Fprintf(out, "  Age:     %s (TTL %s)\n", age, config.FormatCacheTTL(info.TTL))
+			fmt.Fprintf(out, "  Status:  %s\n", status)
+			fmt.Fprintf(out, "  Entries: %d releases\n", info.Releases)
+			fmt.Fprintf(out, "  Source:  %s\n", source)
+		}
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the release cache",
+	Long:  "Delete the cached release list of each tool so the next lookup fetches it again.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tools, err := cacheTools(cmd)
+		if err != nil {
+			return err
+		}
+		for _, tool := range tools {
+			if err := newManager(tool).ClearCache(); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), utils.Success("Release cache cleared"))
+		return nil
+	},
+}
+
+// cacheTools returns the tools selected with --tool, or every tool.
+func cacheTools(cmd *cobra.Command) ([]config.Tool, error) {
+	toolName, _ := cmd.Flags().GetString("tool")
+	if toolName == "" {
+		return config.Tools, nil
+	}
+	tool, err := config.LookupTool(toolName)
+	if err != nil {
+		return nil, err
+	}
+	return []config.Tool{tool}, nil
+}
+
+func init() {
+	cacheCmd.PersistentFlags().String("tool", "", "Only act on the cache of this tool (e.g. esc)")
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
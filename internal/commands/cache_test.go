@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestCacheInfoAndClearCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv(config.CacheTTLEnvVar, "12h")
+
+	data, _ := json.Marshal(config.ReleaseCache{Versions: []string{"3.78.1", "3.78.0"}, Timestamp: time.Now().Add(-time.Hour), SourceKind: "github"})
+	cachePath := config.GetToolCachePath(config.Pulumi)
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"cache", "info"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{cachePath, "TTL 12h0m0s", "fresh", "2 releases", "Source:  github", "Pulumi ESC release cache", "not created yet"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+
+	// The source shown is the one that filled the cache, not the configured
+	// one, and a cache from another source is not used.
+	t.Setenv(config.SourceEnvVar, "mirror")
+	t.Setenv(config.SourceURLEnvVar, "http://mirror.example.com")
+	buf.Reset()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, "Source:  github") || !strings.Contains(out, "filled by another release source") {
+		t.Errorf("expected the cache to be reported as filled by GitHub, got: %s", out)
+	}
+	t.Setenv(config.SourceEnvVar, "")
+	t.Setenv(config.SourceURLEnvVar, "")
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"cache", "clear"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Error("expected the release cache to be removed")
+	}
+}
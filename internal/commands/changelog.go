@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+func init() {
+	changelogCmd.Flags().Bool("breaking", false, "Only show sections about breaking changes")
+	changelogCmd.Flags().Bool("bug-fixes", false, "Only show sections about bug fixes")
+	changelogCmd.Flags().String("tool", config.Pulumi.Name, "Tool to show the changelog of (e.g. esc)")
+}
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog [from] [to]",
+	Short: "Show the release notes between two versions",
+	Long: `Show the release notes of every stable release after <from> up to and
+including <to>, oldest first. <from> defaults to the active version and <to>
+to the latest release; a single argument is taken as <to>.
+
+--breaking and --bug-fixes only show the sections of the notes whose heading
+mentions breaking changes or bug fixes. Release notes come from the GitHub
+releases API.`,
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: completeAvailableVersions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
+		var keywords []string
+		if breaking, _ := cmd.Flags().GetBool("breaking"); breaking {
+			keywords = append(keywords, "breaking")
+		}
+		if bugFixes, _ := cmd.Flags().GetBool("bug-fixes"); bugFixes {
+			keywords = append(keywords, "fix")
+		}
+
+		m := newManager(tool)
+		from, to := "", "latest"
+		switch len(args) {
+		case 2:
+			from, to = args[0], args[1]
+		case 1:
+			to = args[0]
+		}
+		if from == "" {
+			if from, err = m.Current(); err != nil {
+				return fmt.Errorf("failed to get current version: %v", err)
+			}
+			if from == "" {
+				return fmt.Errorf("no active %s version; pass the version to start from", tool.DisplayName)
+			}
+		}
+
+		changelog, err := m.Changelog(cmd.Context(), from, to)
+		if err != nil {
+			return err
+		}
+		if len(changelog) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), utils.Warning("No releases in that range."))
+			return nil
+		}
+
+		shown := 0
+		for _, release := range changelog {
+			notes := release.Notes
+			if len(keywords) > 0 {
+				if notes = notesSections(notes, keywords); notes == "" {
+					continue
+				}
+			}
+			if shown > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			shown++
+
+			header := utils.Current("%s %s", tool.DisplayName, release.Version)
+			if !release.PublishedAt.IsZero() {
+				header += " (" + release.PublishedAt.Format("2006-01-02") + ")"
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), header)
+			if release.NotesURL != "" {
+				fmt.Fprintln(cmd.OutOrStdout(), utils.Info(release.NotesURL))
+			}
+			if strings.TrimSpace(notes) == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No release notes.")
+				continue
+			}
+			fmt.Fprint(cmd.OutOrStdout(), renderNotes(notes))
+		}
+		if shown == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No matching sections in the release notes of %d releases.\n", len(changelog))
+		}
+		return nil
+	},
+}
+
+// markdownHeading returns the level and title of a Markdown heading line, or
+// 0 when line is not a heading.
+func markdownHeading(line string) (int, string) {
+	trimmed := strings.TrimLeft(line, "#")
+	level := len(line) - len(trimmed)
+	if level == 0 || (trimmed != "" && trimmed[0] != ' ') {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(trimmed)
+}
+
+// notesSections returns the sections of Markdown release notes whose
+// heading contains one of keywords, ignoring case, or an empty string when
+// there are none. A section runs until the next heading of the same or a
+// higher level.
+func notesSections(notes string, keywords []string) string {
+	var b strings.Builder
+	sectionLevel := 0
+	for _, line := range strings.Split(strings.ReplaceAll(notes, "\r\n", "\n"), "\n") {
+		if level, title := markdownHeading(line); level > 0 {
+			if sectionLevel > 0 && level <= sectionLevel {
+				sectionLevel = 0
+			}
+			if sectionLevel == 0 {
+				for _, keyword := range keywords {
+					if strings.Contains(strings.ToLower(title), keyword) {
+						sectionLevel = level
+					}
+				}
+			}
+		}
+		if sectionLevel > 0 {
+			b.WriteString(line + "\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// renderNotes formats Markdown release notes for the terminal: headings are
+// highlighted without their markers and lines are indented.
+func renderNotes(notes string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n")), "\n") {
+		if level, title := markdownHeading(line); level > 0 {
+			line = utils.Info(title)
+		}
+		b.WriteString(strings.TrimRight("  "+line, " ") + "\n")
+	}
+	return b.String()
+}
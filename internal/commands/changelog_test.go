@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestNotesSections(t *testing.T) {
+	notes := "## 3.78.1\n\n### Features\n- feature\n\n### Bug Fixes\n- fix\n#### Details\n- detail\n\n### Misc\n- misc"
+	want := "### Bug Fixes\n- fix\n#### Details\n- detail"
+	if got := notesSections(notes, []string{"fix"}); got != want {
+		t.Errorf("notesSections = %q, want %q", got, want)
+	}
+	if got := notesSections(notes, []string{"breaking"}); got != "" {
+		t.Errorf("expected no breaking changes, got %q", got)
+	}
+	if got := notesSections("#hashtag\n- not a heading", []string{"hashtag"}); got != "" {
+		t.Errorf("expected #hashtag not to be a heading, got %q", got)
+	}
+}
+
+func TestChangelogCommand(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	defer mockVersionOperations(t)()
+	defer func() { _ = changelogCmd.Flags().Set("breaking", "false") }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"changelog", "3.77.0", "3.78.1"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{"Pulumi 3.78.0 (2023-08-03)", "Fix a crash", "Pulumi 3.78.1 (2023-08-10)", "Drop an API"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got: %s", want, output)
+		}
+	}
+	if strings.Index(output, "3.78.0") > strings.Index(output, "3.78.1") {
+		t.Errorf("expected the oldest release first, got: %s", output)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"changelog", "3.77.0", "3.78.1", "--breaking"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output = buf.String()
+	if !strings.Contains(output, "Drop an API") || strings.Contains(output, "3.78.0") || strings.Contains(output, "feature") {
+		t.Errorf("expected only the breaking changes of 3.78.1, got: %s", output)
+	}
+
+	// Without an active version there is nothing to start from.
+	rootCmd.SetArgs([]string{"changelog", "--breaking=false"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no active Pulumi version") {
+		t.Errorf("expected a missing active version error, got %v", err)
+	}
+}
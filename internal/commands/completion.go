@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// completionTool splits a "tool@" prefix off the word being completed. Words
+// without a known tool prefix complete Pulumi versions.
+func completionTool(toComplete string) (config.Tool, string) {
+	if name, version, found := strings.Cut(toComplete, "@"); found {
+		if tool, err := config.LookupTool(name); err == nil {
+			return tool, version
+		}
+	}
+	return config.Pulumi, toComplete
+}
+
+// toolSpecs formats versions of tool the way they are typed on the command line.
+func toolSpecs(tool config.Tool, versions []string) []string {
+	specs := make([]string, len(versions))
+	for i, v := range versions {
+		specs[i] = tool.Spec(v)
+	}
+	return specs
+}
+
+// sortedVersions returns versions matching toComplete, newest first.
+func sortedVersions(versions []string, toComplete string) []string {
+	matches := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if strings.HasPrefix(v, toComplete) {
+			matches = append(matches, v)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return utils.SemverGreater(matches[i], matches[j])
+	})
+	return matches
+}
+
+func installedVersionList(tool config.Tool) []string {
+	versions, _ := newManager(tool).List()
+	return versions
+}
+
+// completeInstalledVersions completes the first argument with installed versions.
+func completeInstalledVersions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tool, prefix := completionTool(toComplete)
+	return toolSpecs(tool, sortedVersions(installedVersionList(tool), prefix)), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeAvailableVersions completes the first argument with versions from
+// the release cache. It never contacts GitHub, so completion stays instant
+// and works offline; run 'pvm list --all' to populate the cache.
+func completeAvailableVersions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tool, prefix := completionTool(toComplete)
+	versions, _ := newManager(tool).CachedVersions()
+	completions := sortedVersions(versions, prefix)
+	if strings.HasPrefix("latest", prefix) {
+		completions = append([]string{"latest"}, completions...)
+	}
+	return toolSpecs(tool, completions), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeRemovableVersions completes the first argument with installed
+// versions other than the current one, which cannot be removed.
+func completeRemovableVersions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tool, prefix := completionTool(toComplete)
+	current, _ := newManager(tool).Current()
+	versions := make([]string, 0)
+	for _, v := range installedVersionList(tool) {
+		if v != current {
+			versions = append(versions, v)
+		}
+	}
+	return toolSpecs(tool, sortedVersions(versions, prefix)), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// complete runs cobra's hidden __complete command and returns the suggested
+// completions, without the trailing directive line.
+func complete(t *testing.T, args ...string) []string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"__complete"}, args...))
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var completions []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line != "" && !strings.HasPrefix(line, ":") {
+			completions = append(completions, line)
+		}
+	}
+	return completions
+}
+
+func TestCompleteUseInstalledVersions(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, v := range []string{"3.77.0", "3.78.1", "3.100.0"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, "versions", v), 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	got := complete(t, "use", "")
+	want := []string{"3.100.0", "3.78.1", "3.77.0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("completions = %v, want %v", got, want)
+	}
+
+	got = complete(t, "use", "3.7")
+	want = []string{"3.78.1", "3.77.0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("completions = %v, want %v", got, want)
+	}
+}
+
+func TestCompleteInstallFromCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	// An expired cache is still used: completion must never hit the network.
+	data, _ := json.Marshal(config.ReleaseCache{
+		Versions:   []string{"3.78.1", "3.78.0"},
+		Timestamp:  time.Now().Add(-72 * time.Hour),
+		SourceKind: "github",
+	})
+	if err := os.WriteFile(filepath.Join(tmpDir, config.CacheFile), data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	got := complete(t, "install", "")
+	want := []string{"latest", "3.78.1", "3.78.0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("completions = %v, want %v", got, want)
+	}
+}
+
+func TestCompleteRemoveExcludesCurrent(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.78.1", "pulumi")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.77.0"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	got := complete(t, "remove", "")
+	if len(got) != 1 || got[0] != "3.77.0" {
+		t.Errorf("completions = %v, want [3.77.0]", got)
+	}
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set pvm settings",
+	Long: `Get and set pvm settings.
+
+Settings are read from, in order of precedence:
+  1. command-line flags
+  2. environment variables (e.g. PVM_CACHE_TTL)
+  3. .pvm.toml in the project directory or one of its parents, except for
+     layout, release_source, release_url and github_token_source
+  4. the user configuration file, $XDG_CONFIG_HOME/pvm/config.toml or ~/.pvm/config.toml
+  5. built-in defaults
+
+Run 'pvm config list' to see every setting.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the effective value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, err := config.GetValue(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), value.Value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <key> <value>",
+	Short:             "Set a setting in the user or project configuration file",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFileToEdit(cmd)
+		if err != nil {
+			return err
+		}
+		if err := config.SetValue(path, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s = %s in %s\n", utils.Success("Set"), args[0], args[1], path)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Remove a setting from the user or project configuration file",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFileToEdit(cmd)
+		if err != nil {
+			return err
+		}
+		found, err := config.UnsetValue(path, args[0])
+		if err != nil {
+			return err
+		}
+		if !found {
+			fmt.Fprintln(cmd.OutOrStdout(), utils.Warning(fmt.Sprintf("%s is not set in %s", args[0], path)))
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s in %s\n", utils.Success("Unset"), args[0], path)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every setting with its effective value",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showOrigin, _ := cmd.Flags().GetBool("show-origin")
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		for _, setting := range config.Settings {
+			value, err := config.GetValue(setting.Key)
+			if err != nil {
+				return err
+			}
+			if showOrigin {
+				fmt.Fprintf(w, "%s\t%s = %s\n", value.Origin, setting.Key, value.Value)
+			} else {
+				fmt.Fprintf(w, "%s = %s\n", setting.Key, value.Value)
+			}
+		}
+		return w.Flush()
+	},
+}
+
+// configFileToEdit returns the file 'pvm config set' and 'unset' change: the
+// closest .pvm.toml (or a new one in the working directory) with --project,
+// otherwise the user configuration file.
+func configFileToEdit(cmd *cobra.Command) (string, error) {
+	project, _ := cmd.Flags().GetBool("project")
+	if !project {
+		return config.UserConfigPath(), nil
+	}
+	if path := config.ProjectConfigPath(); path != "" {
+		return path, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %v", err)
+	}
+	return filepath.Join(cwd, config.ProjectConfigFile), nil
+}
+
+// completeSettingKeys completes the key argument of 'pvm config'.
+func completeSettingKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := make([]string, 0, len(config.Settings))
+	for _, setting := range config.Settings {
+		keys = append(keys, setting.Key+"\t"+setting.Description)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	configSetCmd.Flags().Bool("project", false, "Write to the project's .pvm.toml instead of the user configuration")
+	configUnsetCmd.Flags().Bool("project", false, "Remove from the project's .pvm.toml instead of the user configuration")
+	configListCmd.Flags().Bool("show-origin", false, "Show where each value comes from")
+
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestConfigCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(config.CacheTTLEnvVar, "")
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(buf)
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+
+	if _, err := run("config", "set", "cache_ttl", "7d"); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	out, err := run("config", "get", "cache_ttl")
+	if err != nil || strings.TrimSpace(out) != "7d" {
+		t.Errorf("config get = %q, %v; want 7d", out, err)
+	}
+
+	out, err = run("config", "list", "--show-origin")
+	if err != nil {
+		t.Fatalf("config list: %v", err)
+	}
+	userFile := filepath.Join(tmpDir, config.UserConfigFile)
+	if !strings.Contains(out, "file:"+userFile) || !strings.Contains(out, "cache_ttl = 7d") {
+		t.Errorf("expected cache_ttl with its origin, got: %s", out)
+	}
+	if !strings.Contains(out, "default") || !strings.Contains(out, "release_source = github") {
+		t.Errorf("expected defaults to be listed, got: %s", out)
+	}
+
+	if _, err := run("config", "set", "release_source", "ftp"); err == nil {
+		t.Error("expected an invalid value to be rejected")
+	}
+
+	if _, err := run("config", "unset", "cache_ttl"); err != nil {
+		t.Fatalf("config unset: %v", err)
+	}
+	out, _ = run("config", "get", "cache_ttl")
+	if strings.TrimSpace(out) != "24h" {
+		t.Errorf("expected the default after unset, got %q", out)
+	}
+	_ = configListCmd.Flags().Set("show-origin", "false")
+}
+
+func TestVersionArgDefaultVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("PVM_DEFAULT_VERSION", "")
+
+	if version, err := versionArg([]string{"3.78.1"}); err != nil || version != "3.78.1" {
+		t.Errorf("versionArg = %q, %v; want the argument", version, err)
+	}
+	if _, err := versionArg(nil); err == nil {
+		t.Error("expected error without a version, pin file or default_version")
+	}
+
+	if err := config.SetValue(config.UserConfigPath(), "default_version", "3.77.0"); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	if version, err := versionArg(nil); err != nil || version != "3.77.0" {
+		t.Errorf("versionArg = %q, %v; want default_version", version, err)
+	}
+}
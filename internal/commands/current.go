@@ -4,7 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/internal/config"
 )
 
 var currentCmd = &cobra.Command{
@@ -12,17 +12,27 @@ var currentCmd = &cobra.Command{
 	Short: "Show current Pulumi version",
 	Long:  `Display the currently active version of Pulumi.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		version, err := utils.GetCurrentVersion()
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
+
+		version, err := newManager(tool).Current()
 		if err != nil {
 			return fmt.Errorf("failed to get current version: %v", err)
 		}
 
 		if version == "" {
-			fmt.Fprintln(cmd.OutOrStdout(), "No Pulumi version currently selected. Use 'pvm use <version>' to select one.")
+			fmt.Fprintf(cmd.OutOrStdout(), "No %s version currently selected. Use 'pvm use %s' to select one.\n", tool.DisplayName, tool.Spec("<version>"))
 			return nil
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Current Pulumi version: %s\n", version)
+		fmt.Fprintf(cmd.OutOrStdout(), "Current %s version: %s\n", tool.DisplayName, version)
 		return nil
 	},
 }
+
+func init() {
+	currentCmd.Flags().String("tool", config.Pulumi.Name, "Tool to show the current version of")
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the pvm environment",
+	Long: `Check that the pvm bin directory is on PATH, that the active version's
+symlinks resolve, that the release cache and release source are usable, and
+that there is enough disk space and write access under the pvm directory.
+
+Exits non-zero when any check fails (or warns, with --strict), so it can be
+used in onboarding scripts.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		strict, _ := cmd.Flags().GetBool("strict")
+
+		failed := 0
+		// The release source is probed for its latest Pulumi release, through
+		// the same client, and GitHub token, the other commands use.
+		probe := func(ctx context.Context) error {
+			_, err := releaseSource().Latest(ctx, config.Pulumi)
+			return err
+		}
+		for _, result := range utils.RunDiagnostics(cmd.Context(), probe) {
+			var label string
+			switch result.Status {
+			case utils.CheckPass:
+				label = utils.Success("[pass]")
+			case utils.CheckWarn:
+				label = utils.Warning("[warn]")
+			default:
+				label = utils.Error("[fail]")
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s: %s\n", label, result.Name, result.Message)
+			if result.Hint != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "       %s %s\n", utils.Info("fix:"), result.Hint)
+			}
+
+			if result.Status == utils.CheckFail || (strict && result.Status == utils.CheckWarn) {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d check(s) did not pass", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().Bool("strict", false, "Treat warnings as failures")
+}
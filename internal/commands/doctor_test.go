@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestDoctorCommandReportsFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	// An empty PATH guarantees the bin directory check fails.
+	t.Setenv("PATH", "")
+	// So does probing a directory release source without releases.
+	t.Setenv(config.SourceEnvVar, "dir")
+	t.Setenv(config.SourceURLEnvVar, t.TempDir())
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"doctor"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error when a check fails, got nil")
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "[fail] PATH") {
+		t.Errorf("expected failing PATH check, got: %s", out)
+	}
+	if !strings.Contains(out, "[fail] release source: the dir release source at") {
+		t.Errorf("expected failing release source check, got: %s", out)
+	}
+	if !strings.Contains(out, "fix:") {
+		t.Errorf("expected remediation hint, got: %s", out)
+	}
+}
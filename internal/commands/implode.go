@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+func init() {
+	implodeCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+}
+
+var implodeCmd = &cobra.Command{
+	Use:   "implode",
+	Short: "Remove pvm and everything it installed",
+	Long: `Remove every installed version, plugin, cache and configuration file of pvm
+(~/.pvm, PVM_HOME or the XDG directories), pvm's links in Pulumi's plugin
+directory and the lines of your shell profiles that set pvm up, keeping a
+copy of each edited profile. Lines inside if blocks and other lines that
+cannot safely be removed are listed for you to edit by hand. Everything that
+will be removed is listed first and must be confirmed, unless --yes is given.
+The pvm binary itself is left for you to delete.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		plan, err := utils.PlanImplode()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if len(plan.Dirs) == 0 && len(plan.PluginLinks) == 0 && len(plan.ProfileLines) == 0 {
+			fmt.Fprintln(out, "Nothing to remove.")
+			printManualProfileLines(cmd, plan.ManualProfileLines)
+			return nil
+		}
+
+		fmt.Fprintln(out, utils.Warning("This will remove:"))
+		for _, dir := range plan.Dirs {
+			fmt.Fprintf(out, "  %s\n", dir)
+		}
+		for _, link := range plan.PluginLinks {
+			fmt.Fprintf(out, "  %s (plugin link)\n", link)
+		}
+		for _, line := range plan.ProfileLines {
+			fmt.Fprintf(out, "  %s:%d: %s\n", line.Path, line.Number, line.Text)
+		}
+		if len(plan.ProfileLines) > 0 {
+			fmt.Fprintf(out, "Edited shell profiles are backed up with a %s suffix.\n", utils.ProfileBackupSuffix)
+		}
+
+		if !yes {
+			fmt.Fprint(out, "Remove all of this? [y/N] ")
+			answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				fmt.Fprintln(out, "Aborted; nothing was removed.")
+				return nil
+			}
+		}
+
+		if err := utils.Implode(plan); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, utils.Success("pvm has been removed."))
+		printManualProfileLines(cmd, plan.ManualProfileLines)
+		if exe, err := selfExecutable(); err == nil {
+			fmt.Fprintf(out, "Delete %s to finish, and restart your shell.\n", exe)
+		}
+		return nil
+	},
+}
+
+// printManualProfileLines lists the shell profile lines implode leaves for
+// the user to remove.
+func printManualProfileLines(cmd *cobra.Command, lines []utils.ProfileLine) {
+	if len(lines) == 0 {
+		return
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, utils.Warning("These shell profile lines refer to pvm; edit them by hand:"))
+	for _, line := range lines {
+		fmt.Fprintf(out, "  %s:%d: %s\n", line.Path, line.Number, line.Text)
+	}
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestImplodeCommand(t *testing.T) {
+	home := t.TempDir()
+	pvmHome := filepath.Join(home, ".pvm")
+	config.ResetConfig()
+	t.Setenv("HOME", home)
+	t.Setenv("PVM_HOME", pvmHome)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PULUMI_HOME", filepath.Join(home, ".pulumi"))
+	defer func() { _ = implodeCmd.Flags().Set("yes", "false") }()
+
+	if err := os.MkdirAll(filepath.Join(pvmHome, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	bashrc := filepath.Join(home, ".bashrc")
+	block := "if [ -d ~/.pvm/bin ]; then\n  export PATH=~/.pvm/bin:$PATH\nfi\n"
+	if err := os.WriteFile(bashrc, []byte("export PATH=\"$HOME/.pvm/bin:$PATH\"\n"+block), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetIn(strings.NewReader("n\n"))
+	defer rootCmd.SetIn(nil)
+	rootCmd.SetArgs([]string{"implode"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, pvmHome) || !strings.Contains(output, bashrc+":1:") || !strings.Contains(output, "Aborted") {
+		t.Errorf("expected the plan to be listed and aborted, got: %s", output)
+	}
+	if _, err := os.Stat(pvmHome); err != nil {
+		t.Error("expected nothing to be removed without confirmation")
+	}
+
+	buf.Reset()
+	rootCmd.SetIn(strings.NewReader("y\n"))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "pvm has been removed") || !strings.Contains(buf.String(), bashrc+":3: export PATH=~/.pvm/bin:$PATH") {
+		t.Errorf("expected a confirmation message and the lines to edit by hand, got: %s", buf.String())
+	}
+	if _, err := os.Stat(pvmHome); !os.IsNotExist(err) {
+		t.Error("expected PVM_HOME to be removed")
+	}
+	if data, _ := os.ReadFile(bashrc); string(data) != block {
+		t.Errorf("expected only the PATH line outside the if block to be removed from .bashrc, got %q", data)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"implode", "--yes"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Nothing to remove") {
+		t.Errorf("expected nothing left to remove, got: %s", buf.String())
+	}
+}
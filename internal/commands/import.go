@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+func init() {
+	importCmd.Flags().Bool("move", false, "Move the installation into pvm instead of copying it")
+	importCmd.Flags().Bool("replace", false, "Replace the original executable with a link to pvm's")
+	importCmd.Flags().Bool("use", false, "Switch to the imported version")
+	importCmd.Flags().String("tool", config.Pulumi.Name, "Tool to import (e.g. esc)")
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "Adopt an existing Pulumi installation",
+	Long: `Make a Pulumi installation made without pvm a managed version. path is the
+pulumi executable or the directory holding it; without one, the first pulumi
+on PATH outside pvm, ~/.pulumi/bin and the Homebrew and /usr/local/bin
+locations are tried. The version is found by running 'pulumi version'.
+
+pulumi and the binaries Pulumi releases ship next to it (such as
+pulumi-language-go) are copied, or moved with --move; other files in the
+directory are left alone. Installations of package managers such as Homebrew
+can only be copied. With --replace, the original executable becomes a link to
+pvm's, so anything still calling it by path runs the active pvm version.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
+		move, _ := cmd.Flags().GetBool("move")
+		replace, _ := cmd.Flags().GetBool("replace")
+		use, _ := cmd.Flags().GetBool("use")
+
+		path := ""
+		if len(args) > 0 {
+			path = args[0]
+			if info, err := os.Stat(path); err != nil {
+				return err
+			} else if info.IsDir() {
+				path = filepath.Join(path, executableName(tool))
+			}
+		} else if path = findInstallation(tool); path == "" {
+			return fmt.Errorf("no existing %s installation found; pass the path of its executable", tool.DisplayName)
+		}
+
+		m := newManager(tool)
+		version, err := m.Import(cmd.Context(), path, move)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s from %s\n", utils.Success("Imported "+tool.DisplayName), version, path)
+
+		if replace {
+			link := filepath.Join(config.GetBinPath(), executableName(tool))
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to replace %s: %v", path, err)
+			}
+			if err := os.Symlink(link, path); err != nil {
+				return fmt.Errorf("failed to link %s to %s: %v", path, link, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Replaced %s with a link to %s\n", path, link)
+		}
+
+		if use {
+			if err := switchVersion(cmd.Context(), m, version); err != nil {
+				return fmt.Errorf("failed to switch to version %s: %w", version, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Switched to "+tool.DisplayName), version)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Run 'pvm use %s' to switch to it.\n", tool.Spec(version))
+		}
+		return nil
+	},
+}
+
+// executableName returns the file name of tool's executable on this
+// platform.
+func executableName(tool config.Tool) string {
+	if runtime.GOOS == "windows" {
+		return tool.Binary + ".exe"
+	}
+	return tool.Binary
+}
+
+// findInstallation returns the executable of the first installation of tool
+// not managed by pvm: on PATH, from the Pulumi install script or from
+// Homebrew. It returns an empty string when there is none.
+func findInstallation(tool config.Tool) string {
+	name := executableName(tool)
+	candidates := []string{utils.FindOnPath(name)}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".pulumi", "bin", name))
+	}
+	candidates = append(candidates,
+		filepath.Join("/opt/homebrew/bin", name),
+		filepath.Join("/home/linuxbrew/.linuxbrew/bin", name),
+		filepath.Join("/usr/local/bin", name),
+	)
+
+	pvmRoot := filepath.Clean(config.GetPVMPath()) + string(filepath.Separator)
+	if resolved, err := filepath.EvalSymlinks(config.GetPVMPath()); err == nil {
+		pvmRoot = resolved + string(filepath.Separator)
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(candidate)
+		if err != nil || strings.HasPrefix(resolved, pvmRoot) || strings.HasPrefix(filepath.Clean(candidate), filepath.Clean(config.GetBinPath())+string(filepath.Separator)) {
+			continue
+		}
+		return candidate
+	}
+	return ""
+}
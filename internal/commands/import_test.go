@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestImportCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executables are shell scripts")
+	}
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	defer mockVersionOperations(t)()
+	defer func() { _ = importCmd.Flags().Set("replace", "false") }()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pulumi"), []byte("#!/bin/sh\necho v3.90.0"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"import", dir, "--replace"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Imported Pulumi 3.90.0") {
+		t.Errorf("expected an import message, got: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "versions", "3.90.0", "pulumi")); err != nil {
+		t.Errorf("expected 3.90.0 to be installed: %v", err)
+	}
+	target, err := os.Readlink(filepath.Join(dir, "pulumi"))
+	if err != nil || target != filepath.Join(config.GetBinPath(), "pulumi") {
+		t.Errorf("expected the original to link to pvm's pulumi, got %q, %v", target, err)
+	}
+
+	// The replaced executable now points into pvm and is not offered again.
+	if found := findInstallation(config.Pulumi); found == filepath.Join(dir, "pulumi") {
+		t.Errorf("expected findInstallation to skip %s", found)
+	}
+}
@@ -2,47 +2,85 @@ package commands
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
 	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
 )
 
 func installCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "install <version>",
+		Use:   "install [version]",
 		Short: "Install a specific version of Pulumi",
-		Long:  "Install a specific version of Pulumi. Use 'latest' to install the most recent version.",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			version := args[0]
-			useAfterInstall, _ := cmd.Flags().GetBool("use")
+		Long: `Install a specific version of Pulumi. Use 'latest' to install the most recent
+version. Other tools are installed with a tool prefix, e.g. 'pvm install
+esc@0.9.1'. Without a version, the version pinned by the closest
+.pulumi-version file or the default_version setting is installed.
 
-			if version == "latest" {
-				latest, err := utils.GetLatestVersion()
-				if err != nil {
-					return fmt.Errorf("failed to get latest version: %w", err)
-				}
-				version = latest
+With --locked, the archives recorded in the closest pvm.lock (see 'pvm lock')
+are installed instead, and installation fails if an archive does not match
+its locked SHA-256. Without a version every locked tool is installed; a
+version that differs from the locked one is an error.
+
+--os and --arch install a build for another platform, e.g. to stage binaries
+for container images or other machines. Such builds are kept apart from this
+machine's versions and cannot be selected with 'pvm use'.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeAvailableVersions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platformOpts, err := platformOptions(cmd)
+			if err != nil {
+				return err
+			}
+			if locked, _ := cmd.Flags().GetBool("locked"); locked {
+				return installLocked(cmd, args, platformOpts)
 			}
 
-			if err := utils.InstallVersion(version); err != nil {
+			spec, err := versionArg(args)
+			if err != nil {
+				return err
+			}
+			tool, version, err := config.ParseToolVersion(spec)
+			if err != nil {
 				return err
 			}
+			useAfterInstall, _ := cmd.Flags().GetBool("use")
+			m := newManager(tool, platformOpts...)
+			if useAfterInstall && isForeign(m) {
+				return fmt.Errorf("--use cannot be combined with a build for another platform")
+			}
 
-			resolvedVersion, err := utils.ResolveVersion(version)
+			resolvedVersion, err := m.Resolve(cmd.Context(), version)
 			if err != nil {
 				return fmt.Errorf("failed to resolve version: %w", err)
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed Pulumi"), resolvedVersion)
+			if err := m.Install(cmd.Context(), resolvedVersion); err != nil {
+				return err
+			}
+
+			if isForeign(m) {
+				goos, arch := m.Platform()
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s for %s/%s\n", utils.Success("Successfully installed "+tool.DisplayName), resolvedVersion, goos, arch)
+				versionDir, err := m.VersionDir(resolvedVersion)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Info("Installed in:"), versionDir)
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed "+tool.DisplayName), resolvedVersion)
 
 			if useAfterInstall {
-				if err := utils.UseVersion(resolvedVersion); err != nil {
+				if err := switchVersion(cmd.Context(), m, resolvedVersion); err != nil {
 					return fmt.Errorf("failed to switch to version %s: %w", resolvedVersion, err)
 				}
-				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Switched to Pulumi"), resolvedVersion)
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Switched to "+tool.DisplayName), resolvedVersion)
 			} else {
-				fmt.Fprintf(cmd.OutOrStdout(), "\n%s pvm use %s\n", utils.Info("To use this version, run:"), resolvedVersion)
+				fmt.Fprintf(cmd.OutOrStdout(), "\n%s pvm use %s\n", utils.Info("To use this version, run:"), tool.Spec(resolvedVersion))
 			}
 
 			return nil
@@ -50,5 +88,70 @@ func installCmd() *cobra.Command {
 	}
 
 	cmd.Flags().Bool("use", false, "Switch to this version after installing")
+	cmd.Flags().Bool("locked", false, "Install the exact archives recorded in pvm.lock")
+	addPlatformFlags(cmd)
 	return cmd
 }
+
+// installLocked installs the releases recorded in the closest pvm.lock: the
+// one of the tool given in args, or every locked tool.
+func installLocked(cmd *cobra.Command, args []string, platformOpts []pvm.Option) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %v", err)
+	}
+	path := utils.FindLockFile(cwd)
+	if path == "" {
+		return fmt.Errorf("no %s found; run 'pvm lock' to create one", config.LockFile)
+	}
+	lock, err := pvm.ReadLockFile(path)
+	if err != nil {
+		return err
+	}
+
+	var tools []config.Tool
+	if len(args) > 0 {
+		tool, version, err := config.ParseToolVersion(args[0])
+		if err != nil {
+			return err
+		}
+		locked, ok := lock.Tools[tool.Name]
+		if !ok {
+			return fmt.Errorf("%s is not locked in %s", tool.DisplayName, path)
+		}
+		if version != "" && version != "latest" && version != locked.Version {
+			return fmt.Errorf("%s %s does not match version %s locked in %s", tool.DisplayName, version, locked.Version, path)
+		}
+		tools = append(tools, tool)
+	} else {
+		for _, tool := range config.Tools {
+			if _, ok := lock.Tools[tool.Name]; ok {
+				tools = append(tools, tool)
+			}
+		}
+		if len(tools) == 0 {
+			return fmt.Errorf("%s does not lock any tool", path)
+		}
+	}
+
+	useAfterInstall, _ := cmd.Flags().GetBool("use")
+	for _, tool := range tools {
+		locked := lock.Tools[tool.Name]
+		m := newManager(tool, platformOpts...)
+		if useAfterInstall && isForeign(m) {
+			return fmt.Errorf("--use cannot be combined with a build for another platform")
+		}
+		if err := m.InstallLocked(cmd.Context(), locked); err != nil {
+			return fmt.Errorf("failed to install locked %s %s: %w", tool.DisplayName, locked.Version, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed "+tool.DisplayName), locked.Version)
+
+		if useAfterInstall {
+			if err := switchVersion(cmd.Context(), m, locked.Version); err != nil {
+				return fmt.Errorf("failed to switch to version %s: %w", locked.Version, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Switched to "+tool.DisplayName), locked.Version)
+		}
+	}
+	return nil
+}
@@ -2,14 +2,17 @@ package commands
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
-	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/internal/config"
 )
 
 func TestInstallCommand(t *testing.T) {
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -31,7 +34,7 @@ func TestInstallCommand(t *testing.T) {
 }
 
 func TestInstallCommandWithUseFlag(t *testing.T) {
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -53,7 +56,7 @@ func TestInstallCommandWithUseFlag(t *testing.T) {
 }
 
 func TestInstallCommandLatest(t *testing.T) {
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -72,8 +75,44 @@ func TestInstallCommandLatest(t *testing.T) {
 	}
 }
 
+func TestInstallCommandTool(t *testing.T) {
+	cleanup := mockVersionOperations(t)
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"install", "esc@0.9.1", "--use=false"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Successfully installed Pulumi ESC") {
+		t.Errorf("expected ESC install message, got: %s", out)
+	}
+	if !strings.Contains(out, "pvm use esc@0.9.1") {
+		t.Errorf("expected tool-qualified use hint, got: %s", out)
+	}
+}
+
+func TestInstallCommandUnknownTool(t *testing.T) {
+	cleanup := mockVersionOperations(t)
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"install", "nope@1.0.0"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
 func TestInstallCommandMissingArg(t *testing.T) {
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -85,3 +124,51 @@ func TestInstallCommandMissingArg(t *testing.T) {
 		t.Error("expected error for missing version argument, got nil")
 	}
 }
+
+func TestInstallCommandForeignPlatform(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	archive := useReleaseDir(t)
+
+	goos, arch := "darwin", "arm64"
+	if runtime.GOOS == goos && runtime.GOARCH == arch {
+		goos = "linux"
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(archive), config.Pulumi.AssetName("3.78.1", goos, arch)), data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	// Flags keep their state between Execute calls.
+	install, _, _ := rootCmd.Find([]string{"install"})
+	defer func() {
+		_ = install.Flags().Set("os", "")
+		_ = install.Flags().Set("arch", "")
+	}()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"install", "3.78.1", "--os", goos, "--arch", arch})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Successfully installed Pulumi 3.78.1 for "+goos+"/"+arch) {
+		t.Errorf("expected the platform in the install message, got: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, config.PlatformsDir, goos+"-"+arch, "versions", "3.78.1", "pulumi")); err != nil {
+		t.Errorf("expected the build in a platform-qualified directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "versions", "3.78.1")); !os.IsNotExist(err) {
+		t.Error("expected the build to stay out of this machine's versions")
+	}
+
+	rootCmd.SetArgs([]string{"install", "3.78.1", "--os", "plan9"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported platform") {
+		t.Errorf("expected an unsupported platform error, got %v", err)
+	}
+}
@@ -3,8 +3,10 @@ package commands
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
 	"github.com/tomski747/pvm/internal/utils"
 )
 
@@ -15,66 +17,95 @@ var (
 func init() {
 	listCmd.Flags().BoolVar(&refresh, "refresh", false, "Force refresh the version cache")
 	listCmd.Flags().Bool("all", false, "Show all available versions")
+	listCmd.Flags().Bool("include-prereleases", false, "Include pre-releases in --all")
+	listCmd.Flags().String("tool", config.Pulumi.Name, "Tool to list versions of (e.g. esc)")
 }
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List Pulumi versions",
-	Long:  "List installed Pulumi versions. Use --all to show all available versions.",
+	Long: `List installed Pulumi versions and the platform each was built for. Use --all
+to show all available versions with their release dates. Pre-releases are hidden unless --include-prereleases
+is given, and releases without an archive for this platform are left out.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		showAll, _ := cmd.Flags().GetBool("all")
+		includePrereleases, _ := cmd.Flags().GetBool("include-prereleases")
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
 
-		installed := utils.GetInstalledVersions()
-		current, err := utils.GetCurrentVersion()
+		m := newManager(tool)
+		installed, err := installedSet(m)
+		if err != nil {
+			return fmt.Errorf("failed to list installed versions: %v", err)
+		}
+		current, err := m.Current()
 		if err != nil {
 			return fmt.Errorf("failed to get current version: %v", err)
 		}
 
 		if showAll {
-			versions, err := utils.GetAvailableVersions(refresh)
+			releases, err := m.Releases(cmd.Context(), refresh)
 			if err != nil {
 				return fmt.Errorf("failed to fetch available versions: %v", err)
 			}
+			releases = listableReleases(tool, releases, installed, includePrereleases)
 
-			sort.Slice(versions, func(i, j int) bool {
-				return utils.SemverGreater(versions[i], versions[j])
+			sort.Slice(releases, func(i, j int) bool {
+				return utils.SemverGreater(releases[i].Version, releases[j].Version)
 			})
 
+			width := 0
+			for _, release := range releases {
+				width = max(width, len(release.Version))
+			}
+
 			fmt.Fprintln(cmd.OutOrStdout(), utils.Info("Available versions:"))
-			for _, version := range versions {
+			for _, release := range releases {
 				prefix := "  "
-				if installed[version] {
-					if version == current {
+				if installed[release.Version] {
+					if release.Version == current {
 						prefix = utils.Current("→ ")
 					} else {
 						prefix = utils.Success("* ")
 					}
 				}
-				fmt.Fprintf(cmd.OutOrStdout(), "%s%s\n", prefix, version)
+				line := fmt.Sprintf("%s%-*s", prefix, width, release.Version)
+				if !release.PublishedAt.IsZero() {
+					line += "  " + release.PublishedAt.Format("2006-01-02")
+				}
+				if release.Prerelease {
+					line += "  " + utils.Warning("pre-release")
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), strings.TrimRight(line, " "))
 			}
 		} else {
-			if len(installed) == 0 {
-				fmt.Fprintln(cmd.OutOrStdout(), utils.Warning("No versions installed. Use 'pvm install <version>' to install one."))
-				fmt.Fprintln(cmd.OutOrStdout(), utils.Info("Run 'pvm list --all' to see all available versions."))
+			installations, err := m.Installations()
+			if err != nil {
+				return fmt.Errorf("failed to list installed versions: %v", err)
+			}
+			if len(installations) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), utils.Warning(fmt.Sprintf("No versions installed. Use 'pvm install %s' to install one.", tool.Spec("<version>"))))
+				fmt.Fprintln(cmd.OutOrStdout(), utils.Info(fmt.Sprintf("Run '%s' to see all available versions.", listAllCommand(tool))))
 				return nil
 			}
 
-			installedVersions := make([]string, 0, len(installed))
-			for version := range installed {
-				installedVersions = append(installedVersions, version)
+			width := 0
+			for _, installation := range installations {
+				width = max(width, len(installation.Version))
 			}
 
-			sort.Slice(installedVersions, func(i, j int) bool {
-				return utils.SemverGreater(installedVersions[i], installedVersions[j])
-			})
-
+			goos, arch := config.GetPlatformInfo()
 			fmt.Fprintln(cmd.OutOrStdout(), utils.Info("Installed versions:"))
-			for _, version := range installedVersions {
+			for _, installation := range installations {
 				prefix := "  "
-				if version == current {
+				native := installation.OS == goos && installation.Arch == arch
+				if native && installation.Version == current {
 					prefix = utils.Current("→ ")
 				}
-				fmt.Fprintf(cmd.OutOrStdout(), "%s%s\n", prefix, version)
+				fmt.Fprintf(cmd.OutOrStdout(), "%s%-*s  %s/%s\n", prefix, width, installation.Version, installation.OS, installation.Arch)
 			}
 		}
 
@@ -87,3 +118,31 @@ var listCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// listableReleases filters releases down to the ones worth offering: drafts,
+// pre-releases (unless includePrereleases) and releases without an archive
+// for this platform are dropped. Installed versions are always kept.
+func listableReleases(tool config.Tool, releases []config.Release, installed map[string]bool, includePrereleases bool) []config.Release {
+	goos, arch := config.GetPlatformInfo()
+	var listable []config.Release
+	for _, release := range releases {
+		if !installed[release.Version] {
+			if release.Draft || (release.Prerelease && !includePrereleases) {
+				continue
+			}
+			if !release.HasAsset(tool.AssetName(release.Version, goos, arch)) {
+				continue
+			}
+		}
+		listable = append(listable, release)
+	}
+	return listable
+}
+
+// listAllCommand returns the command that lists every available version of tool.
+func listAllCommand(tool config.Tool) string {
+	if tool.Name == config.Pulumi.Name {
+		return "pvm list --all"
+	}
+	return "pvm list --all --tool " + tool.Name
+}
@@ -8,14 +8,15 @@ import (
 	"testing"
 
 	"github.com/tomski747/pvm/internal/config"
-	"github.com/tomski747/pvm/internal/utils"
 )
 
-// resetListFlags resets listCmd's boolean flags to their defaults between tests.
+// resetListFlags resets listCmd's flags to their defaults between tests.
 // pflag does not automatically reset flag values between cobra Execute calls.
 func resetListFlags() {
 	refresh = false
 	_ = listCmd.Flags().Set("all", "false")
+	_ = listCmd.Flags().Set("include-prereleases", "false")
+	_ = listCmd.Flags().Set("tool", config.Pulumi.Name)
 }
 
 func TestListCommandEmpty(t *testing.T) {
@@ -67,12 +68,44 @@ func TestListCommandWithInstalled(t *testing.T) {
 	}
 }
 
+func TestListCommandTool(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	resetListFlags()
+	defer resetListFlags()
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(config.GetToolVersionsPath(config.ESC), "0.9.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"list", "--tool", "esc"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "0.9.1") {
+		t.Errorf("expected esc 0.9.1 in output, got: %s", out)
+	}
+	if strings.Contains(out, "3.78.1") {
+		t.Errorf("expected pulumi versions to be excluded, got: %s", out)
+	}
+}
+
 func TestListCommandAll(t *testing.T) {
 	tmpDir := t.TempDir()
 	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
 	defer config.ResetConfig()
 
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 	resetListFlags()
 
@@ -130,3 +163,81 @@ func TestListCommandSortOrder(t *testing.T) {
 			pos100, pos78, pos77, out)
 	}
 }
+
+func TestListCommandAllFiltersReleases(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	cleanup := mockVersionOperations(t)
+	defer cleanup()
+	resetListFlags()
+	defer resetListFlags()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"list", "--all"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "3.78.1  2023-08-10") {
+		t.Errorf("expected release dates in output, got: %s", out)
+	}
+	for _, hidden := range []string{"3.79.0", "3.77.5"} {
+		if strings.Contains(out, hidden) {
+			t.Errorf("expected %s to be hidden, got: %s", hidden, out)
+		}
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"list", "--all", "--include-prereleases"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out = buf.String()
+	if !strings.Contains(out, "3.79.0-alpha.1") || !strings.Contains(out, "pre-release") {
+		t.Errorf("expected the pre-release to be listed, got: %s", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "3.79.0" {
+			t.Errorf("expected the draft to stay hidden, got: %s", out)
+		}
+	}
+}
+
+func TestListCommandShowsPlatforms(t *testing.T) {
+	tmpDir := t.TempDir()
+	goos, arch := config.GetPlatformInfo()
+	for _, dir := range []string{
+		filepath.Join(tmpDir, "versions", "3.78.1"),
+		filepath.Join(tmpDir, config.PlatformsDir, "plan9-amd64", "versions", "3.90.0"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	resetListFlags()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"list"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"3.78.1  " + goos + "/" + arch, "3.90.0  plan9/amd64"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+func init() {
+	lockCmd.Flags().StringSlice("platforms", nil, "Platforms to lock, as os/arch (default: every platform the release supports)")
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock [version]",
+	Short: "Record exact release archives in pvm.lock",
+	Long: `Record the exact version, per-platform archive URLs and SHA-256 checksums of a
+release in pvm.lock, so 'pvm install --locked' installs the very same archives
+on every machine. Other tools are locked with a tool prefix, e.g.
+'pvm lock esc@0.9.1'. Without a version, the version pinned by the closest
+.pulumi-version file or the default_version setting is locked.
+
+The closest pvm.lock in the working directory or its parents is updated;
+without one, pvm.lock is created in the working directory.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeAvailableVersions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, err := versionArg(args)
+		if err != nil {
+			return err
+		}
+		tool, version, err := config.ParseToolVersion(spec)
+		if err != nil {
+			return err
+		}
+		platforms, _ := cmd.Flags().GetStringSlice("platforms")
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %v", err)
+		}
+		path := utils.FindLockFile(cwd)
+		lock := &pvm.LockFile{Tools: make(map[string]pvm.LockedRelease)}
+		if path == "" {
+			path = filepath.Join(cwd, config.LockFile)
+		} else if lock, err = pvm.ReadLockFile(path); err != nil {
+			return err
+		}
+
+		locked, err := newManager(tool).Lock(cmd.Context(), version, platforms)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", tool.DisplayName, err)
+		}
+		lock.Tools[tool.Name] = locked
+		if err := pvm.WriteLockFile(path, lock); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s for %d platform(s) in %s\n", utils.Success("Locked "+tool.DisplayName), locked.Version, len(locked.Artifacts), path)
+		return nil
+	},
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+// useReleaseDir makes the commands install from a directory holding a
+// Pulumi 3.78.1 archive for the current platform, and returns the archive's
+// path.
+func useReleaseDir(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("release archives are zip files on Windows")
+	}
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	content := "#!/bin/sh\necho v3.78.1"
+	if err := tw.WriteHeader(&tar.Header{Name: "pulumi/pulumi", Mode: 0755, Size: int64(len(content))}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	dir := t.TempDir()
+	goos, arch := config.GetPlatformInfo()
+	archive := filepath.Join(dir, config.Pulumi.AssetName("3.78.1", goos, arch))
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	origNewManager := newManager
+	newManager = func(tool config.Tool, opts ...pvm.Option) versionManager {
+		return pvm.New(append(layoutOptions(pvm.WithTool(tool), pvm.WithReleaseSource(pvm.NewDirSource(dir))), opts...)...)
+	}
+	t.Cleanup(func() { newManager = origNewManager })
+	return archive
+}
+
+func TestLockAndInstallLocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	archive := useReleaseDir(t)
+	// Flags keep their state between Execute calls.
+	defer func() {
+		install, _, _ := rootCmd.Find([]string{"install"})
+		_ = install.Flags().Set("locked", "false")
+	}()
+
+	project := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"lock", "3.78.1"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Locked Pulumi 3.78.1 for 1 platform(s)") {
+		t.Errorf("expected a lock message, got: %s", buf.String())
+	}
+	if _, err := pvm.ReadLockFile(filepath.Join(project, config.LockFile)); err != nil {
+		t.Fatalf("expected a readable lock file: %v", err)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"install", "--locked"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("install --locked: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "versions", "3.78.1", "pulumi")); err != nil {
+		t.Errorf("expected 3.78.1 to be installed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"install", "--locked", "3.78.0"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected a version mismatch error, got %v", err)
+	}
+
+	// A re-published archive no longer matches the lock file.
+	if err := os.RemoveAll(filepath.Join(tmpDir, "versions", "3.78.1")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(archive, []byte("tampered"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	rootCmd.SetArgs([]string{"install", "--locked"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch error, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+// versionManager is the part of *pvm.Manager the commands use. Tests replace
+// newManager to avoid touching the network.
+type versionManager interface {
+	Tool() config.Tool
+	Platform() (string, string)
+	Releases(ctx context.Context, refresh bool) ([]config.Release, error)
+	Available(ctx context.Context, refresh bool) ([]string, error)
+	CachedVersions() ([]string, error)
+	CacheInfo() (pvm.CacheInfo, error)
+	ClearCache() error
+	Resolve(ctx context.Context, version string) (string, error)
+	Install(ctx context.Context, version string) error
+	Lock(ctx context.Context, version string, platforms []string) (pvm.LockedRelease, error)
+	InstallLocked(ctx context.Context, locked pvm.LockedRelease) error
+	AddToBundle(ctx context.Context, dir, version string, platforms []string) (string, error)
+	ImportBundle(ctx context.Context, dir string) ([]string, error)
+	SyncMirror(ctx context.Context, dir, constraint string, platforms []string) ([]string, error)
+	Changelog(ctx context.Context, from, to string) ([]pvm.ReleaseNotes, error)
+	List() ([]string, error)
+	Installations() ([]pvm.Installation, error)
+	Import(ctx context.Context, path string, move bool) (string, error)
+	Current() (string, error)
+	ResolveInstalled(version string) (string, error)
+	Use(version string) error
+	Remove(version string) error
+	VersionDir(version string) (string, error)
+	ExecutablePath(version, name string) (string, error)
+}
+
+// newManager returns the manager for tool rooted at the PVM directory, using
+// the configured release source and cache TTL. opts are applied last.
+var newManager = func(tool config.Tool, opts ...pvm.Option) versionManager {
+	opts = append(layoutOptions(pvm.WithTool(tool), pvm.WithReleaseSource(releaseSource()), pvm.WithCacheTTL(cacheTTL())), opts...)
+	return pvm.New(opts...)
+}
+
+// layoutOptions returns opts preceded by the options placing a manager in
+// the directories of the configured layout and printing its warnings.
+func layoutOptions(opts ...pvm.Option) []pvm.Option {
+	return append([]pvm.Option{
+		pvm.WithRoot(config.GetPVMPath()),
+		pvm.WithCacheDir(config.GetCacheDir()),
+		pvm.WithWarningHandler(printWarning),
+	}, opts...)
+}
+
+// printWarning reports a failure that did not stop the command.
+func printWarning(err error) {
+	fmt.Fprintln(os.Stderr, utils.Warning(fmt.Sprintf("Warning: %v", err)))
+}
+
+// cacheTTL returns the configured release cache TTL. An invalid setting is
+// reported and the default used, so a typo does not break every command.
+func cacheTTL() time.Duration {
+	ttl, err := config.GetCacheTTL()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, utils.Warning(fmt.Sprintf("Warning: %v; using %s", err, config.FormatCacheTTL(config.CacheTTL))))
+		return config.CacheTTL
+	}
+	return ttl
+}
+
+// releaseSource returns the release source selected by the release_source
+// and release_url settings. GitHub API requests carry the token found
+// through github_token_source, which is only looked up once the API is
+// called. A misconfigured source is reported when it is first used, so
+// commands that never contact it keep working.
+func releaseSource() pvm.ReleaseSource {
+	kind, location, err := config.GetReleaseSource()
+	if err != nil {
+		return invalidSource{err}
+	}
+	client := http.DefaultClient
+	if kind == "github" {
+		client = utils.LazyGitHubClient(utils.GitHubToken)
+	}
+	source, err := pvm.NewReleaseSource(kind, location, client)
+	if err != nil {
+		return invalidSource{err}
+	}
+	return source
+}
+
+// invalidSource is a ReleaseSource that fails every call with err.
+type invalidSource struct {
+	err error
+}
+
+func (s invalidSource) Releases(ctx context.Context, tool config.Tool) ([]config.Release, error) {
+	return nil, s.err
+}
+
+func (s invalidSource) Latest(ctx context.Context, tool config.Tool) (string, error) {
+	return "", s.err
+}
+
+func (s invalidSource) AssetURL(ctx context.Context, tool config.Tool, version, goos, arch string) (string, error) {
+	return "", s.err
+}
+
+func (s invalidSource) Checksum(ctx context.Context, tool config.Tool, version, goos, arch string) (string, error) {
+	return "", s.err
+}
+
+// addPlatformFlags adds the --os and --arch flags that select a platform
+// other than the running one.
+func addPlatformFlags(cmd *cobra.Command) {
+	cmd.Flags().String("os", "", "Operating system of the build (e.g. darwin); defaults to this machine's")
+	cmd.Flags().String("arch", "", "Architecture of the build (e.g. arm64); defaults to this machine's")
+}
+
+// platformOptions returns the manager options selecting the platform given
+// by the --os and --arch flags, or none when neither is set.
+func platformOptions(cmd *cobra.Command) ([]pvm.Option, error) {
+	goos, _ := cmd.Flags().GetString("os")
+	arch, _ := cmd.Flags().GetString("arch")
+	if goos == "" && arch == "" {
+		return nil, nil
+	}
+	runtimeOS, runtimeArch := config.GetPlatformInfo()
+	if goos == "" {
+		goos = runtimeOS
+	}
+	if arch == "" {
+		arch = runtimeArch
+	}
+	if !slices.Contains(pvm.LockPlatforms, goos+"/"+arch) {
+		return nil, fmt.Errorf("unsupported platform %s/%s (supported: %s)", goos, arch, strings.Join(pvm.LockPlatforms, ", "))
+	}
+	return []pvm.Option{pvm.WithPlatform(goos, arch)}, nil
+}
+
+// isForeign reports whether m installs builds for another platform than
+// the running one.
+func isForeign(m versionManager) bool {
+	goos, arch := m.Platform()
+	runtimeOS, runtimeArch := config.GetPlatformInfo()
+	return goos != runtimeOS || arch != runtimeArch
+}
+
+// installedSet returns the installed versions of m's tool as a set.
+func installedSet(m versionManager) (map[string]bool, error) {
+	versions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	installed := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		installed[v] = true
+	}
+	return installed, nil
+}
+
+// switchVersion makes version the active one and, for the Pulumi CLI, links
+// the provider plugins associated with it.
+func switchVersion(ctx context.Context, m versionManager, version string) error {
+	if err := m.Use(version); err != nil {
+		return err
+	}
+	if m.Tool().Name == config.Pulumi.Name {
+		if err := utils.SyncPlugins(ctx, version); err != nil {
+			return fmt.Errorf("failed to switch plugins: %v", err)
+		}
+	}
+	return nil
+}
+
+// versionArg returns the version given on the command line or, without one,
+// the version pinned for the working directory or the default_version
+// setting.
+func versionArg(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %v", err)
+	}
+	pinned, pinFile, err := utils.GetPinnedVersion(cwd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", pinFile, err)
+	}
+	if pinned != "" {
+		return pinned, nil
+	}
+
+	defaultVersion, err := config.GetString("default_version")
+	if err != nil {
+		return "", err
+	}
+	if defaultVersion == "" {
+		return "", fmt.Errorf("no version given; pass one, pin one in %s or run 'pvm config set default_version <version>'", config.PinFile)
+	}
+	return defaultVersion, nil
+}
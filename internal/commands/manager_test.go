@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+// fakeManager is a real manager for local state whose network-dependent
+// operations are stubbed out.
+type fakeManager struct {
+	*pvm.Manager
+}
+
+func (f fakeManager) Releases(ctx context.Context, refresh bool) ([]config.Release, error) {
+	goos, arch := config.GetPlatformInfo()
+	published := func(v string, day int) config.Release {
+		return config.Release{
+			Version:     v,
+			PublishedAt: time.Date(2023, 8, day, 0, 0, 0, 0, time.UTC),
+			Assets:      []string{f.Tool().AssetName(v, goos, arch)},
+		}
+	}
+	return []config.Release{
+		{Version: "3.79.0", Draft: true},
+		{Version: "3.79.0-alpha.1", Prerelease: true},
+		published("3.78.1", 10),
+		published("3.78.0", 3),
+		{Version: "3.77.5", Assets: []string{}},
+		published("3.77.0", 1),
+	}, nil
+}
+
+func (f fakeManager) Available(ctx context.Context, refresh bool) ([]string, error) {
+	return []string{"3.78.1", "3.78.0", "3.77.0"}, nil
+}
+
+func (f fakeManager) Resolve(ctx context.Context, version string) (string, error) {
+	if version == "latest" {
+		return "3.78.1", nil
+	}
+	// Treat the version string as already resolved
+	return version, nil
+}
+
+func (f fakeManager) Changelog(ctx context.Context, from, to string) ([]pvm.ReleaseNotes, error) {
+	return []pvm.ReleaseNotes{
+		{
+			Release: config.Release{Version: "3.78.0", PublishedAt: time.Date(2023, 8, 3, 0, 0, 0, 0, time.UTC)},
+			Notes:   "### Features\n- [cli] Add a feature\n\n### Bug Fixes\n- [engine] Fix a crash",
+		},
+		{
+			Release: config.Release{Version: "3.78.1", PublishedAt: time.Date(2023, 8, 10, 0, 0, 0, 0, time.UTC)},
+			Notes:   "### Breaking Changes\n- [sdk] Drop an API\n\n### Features\n- [cli] Add another feature",
+		},
+	}, nil
+}
+
+func (f fakeManager) Install(ctx context.Context, version string) error {
+	return nil
+}
+
+func (f fakeManager) Use(version string) error {
+	return nil
+}
+
+// mockVersionOperations makes the commands use fakeManager and stubs out
+// plugin downloads. It returns a cleanup function that restores the originals.
+func mockVersionOperations(t testing.TB) func() {
+	t.Helper()
+
+	origNewManager := newManager
+	newManager = func(tool config.Tool, opts ...pvm.Option) versionManager {
+		return fakeManager{pvm.New(append(layoutOptions(pvm.WithTool(tool)), opts...)...)}
+	}
+	restorePlugins := utils.MockPluginOperations(t)
+
+	return func() {
+		newManager = origNewManager
+		restorePlugins()
+	}
+}
+
+func TestSwitchVersionSyncsPlugins(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+
+	versionDir := filepath.Join(tmpDir, "versions", "3.78.1")
+	pluginDir := filepath.Join(tmpDir, "plugins", "resource-aws-v6.0.0")
+	for _, dir := range []string{versionDir, pluginDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(versionDir, "pulumi"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	plugin := utils.Plugin{Name: "aws", Version: "6.0.0"}
+	if err := utils.AddPluginToSet("3.78.1", plugin); err != nil {
+		t.Fatalf("AddPluginToSet: %v", err)
+	}
+
+	if err := switchVersion(context.Background(), newManager(config.Pulumi), "3.78.1"); err != nil {
+		t.Fatalf("switchVersion: %v", err)
+	}
+	if !utils.IsPluginLinked(plugin) {
+		t.Error("expected aws 6.0.0 to be linked after switching to 3.78.1")
+	}
+}
+
+func TestReleaseSourceFromEnv(t *testing.T) {
+	t.Setenv(config.SourceEnvVar, "dir")
+	t.Setenv(config.SourceURLEnvVar, t.TempDir())
+	if _, ok := releaseSource().(*pvm.DirSource); !ok {
+		t.Errorf("expected a directory release source, got %T", releaseSource())
+	}
+
+	// A misconfigured source only fails once it is used.
+	t.Setenv(config.SourceURLEnvVar, "")
+	_, err := releaseSource().Releases(context.Background(), config.Pulumi)
+	if err == nil {
+		t.Error("expected error for a dir source without a location, got nil")
+	}
+}
+
+func TestReleaseSourceDefersTokenLookup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the GitHub CLI")
+	}
+	binDir := t.TempDir()
+	marker := filepath.Join(binDir, "called")
+	script := "#!/bin/sh\ntouch " + marker + "\necho token\n"
+	if err := os.WriteFile(filepath.Join(binDir, "gh"), []byte(script), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+	t.Setenv(config.SourceEnvVar, "github")
+	t.Setenv("PVM_GITHUB_TOKEN_SOURCE", "gh")
+
+	releaseSource()
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("expected the GitHub CLI not to run until the API is called")
+	}
+}
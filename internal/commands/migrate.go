@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+func init() {
+	migrateCmd.Flags().String("to", config.LayoutXDG, "Layout to migrate to (xdg or home)")
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move pvm's files to another directory layout",
+	Long: `Move installed versions, plugins, release caches and the configuration file
+to another directory layout and select it in the configuration.
+
+The home layout keeps everything under ~/.pvm. The xdg layout follows the XDG
+Base Directory specification: versions and plugins go to $XDG_DATA_HOME/pvm,
+release caches to $XDG_CACHE_HOME/pvm and the configuration file to
+$XDG_CONFIG_HOME/pvm. Symlinks in the bin directory are rewritten, so the
+active version keeps working once the new bin directory is on your PATH.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("to")
+		if name != config.LayoutHome && name != config.LayoutXDG {
+			return fmt.Errorf("unknown layout %q (expected home or xdg)", name)
+		}
+		if os.Getenv("PVM_HOME") != "" {
+			return fmt.Errorf("PVM_HOME is set; unset it before migrating to another layout")
+		}
+
+		from := config.CurrentLayout()
+		to := config.GetLayout(name)
+		if from.Name == to.Name {
+			return fmt.Errorf("pvm already uses the %s layout", to.Name)
+		}
+
+		if err := utils.MigrateLayout(from, to); err != nil {
+			return err
+		}
+		if err := config.SetValue(filepath.Join(to.ConfigDir, config.UserConfigFile), "layout", to.Name); err != nil {
+			return fmt.Errorf("moved files but failed to select the %s layout: %v", to.Name, err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, utils.Success(fmt.Sprintf("Migrated pvm to the %s layout", to.Name)))
+		if to.DataDir != from.DataDir {
+			binDir := filepath.Join(to.DataDir, config.BinDir)
+			fmt.Fprintln(out, utils.Info(fmt.Sprintf("Replace %s with %s in your PATH,", filepath.Join(from.DataDir, config.BinDir), binDir)))
+			fmt.Fprintln(out, utils.Info("or re-run 'pvm init' and restart your shell."))
+		}
+		if os.Getenv("PVM_LAYOUT") != "" {
+			fmt.Fprintln(out, utils.Warning("PVM_LAYOUT is set and overrides the layout setting; update or unset it"))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	xdgDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("PVM_HOME", "")
+	t.Setenv("PVM_LAYOUT", "")
+	t.Setenv("PULUMI_HOME", filepath.Join(xdgDir, "pulumi"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(xdgDir, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(xdgDir, "cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(xdgDir, "config"))
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"migrate", "--to", "xdg"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Migrated pvm to the xdg layout") {
+		t.Errorf("expected a success message, got: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(xdgDir, "data", "pvm", "versions", "3.78.1")); err != nil {
+		t.Errorf("expected the version to be moved: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(xdgDir, "config", "pvm", config.UserConfigFile))
+	if err != nil || !strings.Contains(string(data), `layout = "xdg"`) {
+		t.Errorf("expected the xdg layout to be selected, got %q (%v)", data, err)
+	}
+}
+
+func TestMigrateCommandSameLayout(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	t.Setenv("PVM_HOME", "")
+
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"migrate", "--to", "home"})
+
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "already uses the home layout") {
+		t.Fatalf("expected an already-migrated error, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Run a release mirror for other machines",
+	Long: `Download releases into a mirror directory and serve them over HTTP in the
+layout of the GitHub API and release downloads, so machines on the local
+network can install without reaching GitHub:
+
+  pvm mirror sync --versions '>=3.80'
+  pvm mirror serve --addr :8080
+
+and on the other machines:
+
+  pvm config set release_source mirror
+  pvm config set release_url http://<mirror host>:8080`,
+}
+
+var mirrorSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download releases into the mirror directory",
+	Long: `Download the archives of the stable releases matching --versions into the
+mirror directory. --versions takes comma-separated comparisons such as
+'>=3.80, <4' or a version prefix such as '3.80'. Archives already in the
+mirror are not downloaded again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		constraint, _ := cmd.Flags().GetString("versions")
+		platforms, _ := cmd.Flags().GetStringSlice("platforms")
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
+		dir := mirrorDir(cmd)
+
+		synced, err := newManager(tool).SyncMirror(cmd.Context(), dir, constraint, platforms)
+		for _, version := range synced {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Info("Mirrored "+tool.DisplayName), version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(synced) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), utils.Success(fmt.Sprintf("Mirror in %s is up to date", dir)))
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), utils.Success(fmt.Sprintf("Mirrored %d release(s) into %s", len(synced), dir)))
+		}
+		return nil
+	},
+}
+
+var mirrorServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the mirror directory over HTTP",
+	Long: `Serve the mirror directory, or any directory holding release archives under
+their original names, over HTTP until interrupted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		dir := mirrorDir(cmd)
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %v", addr, err)
+		}
+		server := &http.Server{Handler: pvm.NewMirrorHandler(dir), ReadHeaderTimeout: 10 * time.Second}
+
+		go func() {
+			<-cmd.Context().Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s on http://%s\n", utils.Success("Serving"), dir, listener.Addr())
+		fmt.Fprintln(cmd.OutOrStdout(), utils.Info("Point other machines at it with 'pvm config set release_source mirror'"))
+		fmt.Fprintln(cmd.OutOrStdout(), utils.Info("and 'pvm config set release_url http://<this host>:<port>'."))
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// mirrorDir returns the directory given with --dir, or the default mirror
+// directory.
+func mirrorDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	return config.GetMirrorPath()
+}
+
+func init() {
+	mirrorCmd.PersistentFlags().String("dir", "", "Mirror directory (default: the mirror in pvm's cache directory)")
+	mirrorSyncCmd.Flags().String("versions", "", "Versions to mirror, e.g. '>=3.80'")
+	_ = mirrorSyncCmd.MarkFlagRequired("versions")
+	mirrorSyncCmd.Flags().StringSlice("platforms", nil, "Platforms to mirror, as os/arch (default: every platform a release supports)")
+	mirrorSyncCmd.Flags().String("tool", config.Pulumi.Name, "Tool to mirror (e.g. esc)")
+	mirrorServeCmd.Flags().String("addr", ":8080", "Address to listen on")
+
+	mirrorCmd.AddCommand(mirrorSyncCmd)
+	mirrorCmd.AddCommand(mirrorServeCmd)
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestMirrorSyncCommand(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	archive := useReleaseDir(t)
+	mirror := t.TempDir()
+	goos, arch := config.GetPlatformInfo()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"mirror", "sync", "--versions", ">=3.78", "--platforms", goos + "/" + arch, "--dir", mirror})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Mirrored Pulumi 3.78.1") {
+		t.Errorf("expected a mirrored message, got: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(mirror, filepath.Base(archive))); err != nil {
+		t.Errorf("expected the archive in the mirror: %v", err)
+	}
+
+	buf.Reset()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "is up to date") {
+		t.Errorf("expected the mirror to be up to date, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+func init() {
+	outdatedCmd.Flags().Bool("json", false, "Print the report as JSON")
+	outdatedCmd.Flags().String("fail-if-older-than", "", "Fail if a reported version was released longer ago than this duration (e.g. 90d)")
+	outdatedCmd.Flags().String("tool", config.Pulumi.Name, "Tool to report on (e.g. esc)")
+}
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Show how far the active and pinned versions are behind",
+	Long: `Report the active version and each version pinned for the working directory
+(by .pulumi-version, the default_version of .pvm.toml and pvm.lock) next to the
+newest patch of its minor version, the newest minor version of its major
+version and the newest release overall. The status column says which kind of
+newer release exists: patch, minor or major.
+
+With --fail-if-older-than, the command fails if any reported version was
+released longer ago than the given duration, e.g. 'pvm outdated
+--fail-if-older-than 90d' in CI. It also fails when the release date of a
+reported version is unknown, e.g. with a release source that does not list
+dates.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
+		var maxAge time.Duration
+		failIfOlderThan, _ := cmd.Flags().GetString("fail-if-older-than")
+		if failIfOlderThan != "" {
+			if maxAge, err = config.ParseDuration(failIfOlderThan); err != nil {
+				return err
+			}
+			if maxAge <= 0 {
+				return fmt.Errorf("invalid --fail-if-older-than %q: must be longer than 0", failIfOlderThan)
+			}
+		}
+
+		m := newManager(tool)
+		pinned, err := pinnedVersions(m)
+		if err != nil {
+			return err
+		}
+		if len(pinned) == 0 {
+			if asJSON {
+				fmt.Fprintln(cmd.OutOrStdout(), "[]")
+				return nil
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), utils.Warning(fmt.Sprintf("No active or pinned %s version.", tool.DisplayName)))
+			return nil
+		}
+
+		releases, err := m.Releases(cmd.Context(), false)
+		if err != nil {
+			return fmt.Errorf("failed to fetch available versions: %v", err)
+		}
+		now := time.Now()
+		var reports []outdatedReport
+		for _, p := range pinned {
+			version, err := m.Resolve(cmd.Context(), p.version)
+			if err != nil {
+				return fmt.Errorf("failed to resolve %s from %s: %w", p.version, p.source, err)
+			}
+			reports = append(reports, newOutdatedReport(p.source, version, releases, now))
+		}
+
+		if asJSON {
+			data, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		} else {
+			printOutdatedReports(cmd, reports)
+		}
+
+		if maxAge > 0 {
+			var old, undated []string
+			for _, r := range reports {
+				if r.PublishedAt == nil {
+					undated = append(undated, fmt.Sprintf("%s (%s)", r.Version, r.Source))
+				} else if now.Sub(*r.PublishedAt) > maxAge {
+					old = append(old, fmt.Sprintf("%s (%s, released %s)", r.Version, r.Source, r.PublishedAt.Format("2006-01-02")))
+				}
+			}
+			if len(old) > 0 {
+				return fmt.Errorf("%s versions older than %s: %s", tool.DisplayName, failIfOlderThan, strings.Join(old, ", "))
+			}
+			if len(undated) > 0 {
+				return fmt.Errorf("%s versions with an unknown release date: %s", tool.DisplayName, strings.Join(undated, ", "))
+			}
+		}
+		return nil
+	},
+}
+
+// pinnedVersion is a version in use and where it is set.
+type pinnedVersion struct {
+	source  string
+	version string
+}
+
+// pinnedVersions returns the active version of m's tool followed by the
+// versions pinned for the working directory: by the closest pin file and
+// project configuration for the Pulumi CLI, and by the closest pvm.lock.
+func pinnedVersions(m versionManager) ([]pinnedVersion, error) {
+	var pinned []pinnedVersion
+	current, err := m.Current()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current version: %v", err)
+	}
+	if current != "" {
+		pinned = append(pinned, pinnedVersion{"active", current})
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %v", err)
+	}
+	if m.Tool().Name == config.Pulumi.Name {
+		version, path, err := utils.GetPinnedVersion(cwd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		if version != "" {
+			pinned = append(pinned, pinnedVersion{relativePath(cwd, path), version})
+		}
+
+		if path := config.ProjectConfigPath(); path != "" {
+			values, err := config.ReadConfigFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", path, err)
+			}
+			if version := values["default_version"]; version != "" {
+				pinned = append(pinned, pinnedVersion{relativePath(cwd, path), version})
+			}
+		}
+	}
+
+	if path := utils.FindLockFile(cwd); path != "" {
+		lock, err := pvm.ReadLockFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if locked, ok := lock.Tools[m.Tool().Name]; ok {
+			pinned = append(pinned, pinnedVersion{relativePath(cwd, path), locked.Version})
+		}
+	}
+	return pinned, nil
+}
+
+// relativePath returns path relative to dir when it can be expressed so.
+func relativePath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// outdatedReport compares a version in use with the newest releases.
+type outdatedReport struct {
+	Source      string     `json:"source"`
+	Version     string     `json:"version"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	AgeDays     *int       `json:"age_days,omitempty"`
+	LatestPatch string     `json:"latest_patch"`
+	LatestMinor string     `json:"latest_minor"`
+	Latest      string     `json:"latest"`
+	// Status is "up-to-date", or the most significant kind of newer
+	// release: "patch", "minor" or "major".
+	Status string `json:"status"`
+}
+
+// newOutdatedReport compares version with the stable releases.
+func newOutdatedReport(source, version string, releases []config.Release, now time.Time) outdatedReport {
+	report := outdatedReport{Source: source, Version: version}
+	var stable []string
+	for _, release := range releases {
+		if release.Version == version && !release.PublishedAt.IsZero() {
+			published := release.PublishedAt
+			days := int(now.Sub(published).Hours() / 24)
+			report.PublishedAt, report.AgeDays = &published, &days
+		}
+		if !release.Draft && !release.Prerelease {
+			stable = append(stable, release.Version)
+		}
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) >= 2 {
+		report.LatestPatch, _ = utils.FindLatestMatchingVersion(parts[0]+"."+parts[1], stable)
+	}
+	report.LatestMinor, _ = utils.FindLatestMatchingVersion(parts[0], stable)
+	for _, v := range stable {
+		if report.Latest == "" || utils.SemverGreater(v, report.Latest) {
+			report.Latest = v
+		}
+	}
+
+	switch {
+	case report.Latest != "" && utils.SemverGreater(report.Latest, version) && report.Latest != report.LatestMinor:
+		report.Status = "major"
+	case report.LatestMinor != "" && utils.SemverGreater(report.LatestMinor, version) && report.LatestMinor != report.LatestPatch:
+		report.Status = "minor"
+	case report.LatestPatch != "" && utils.SemverGreater(report.LatestPatch, version):
+		report.Status = "patch"
+	default:
+		report.Status = "up-to-date"
+	}
+	return report
+}
+
+// statusColor returns the printer for a report status: the further behind,
+// the more alarming.
+func statusColor(status string) func(string, ...interface{}) string {
+	switch status {
+	case "up-to-date":
+		return utils.Success
+	case "patch":
+		return utils.Info
+	case "minor":
+		return utils.Warning
+	default:
+		return utils.Error
+	}
+}
+
+// printOutdatedReports prints reports as a table.
+func printOutdatedReports(cmd *cobra.Command, reports []outdatedReport) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tVERSION\tRELEASED\tLATEST PATCH\tLATEST MINOR\tLATEST\tSTATUS")
+	for _, r := range reports {
+		released := "-"
+		if r.PublishedAt != nil {
+			released = fmt.Sprintf("%s (%dd ago)", r.PublishedAt.Format("2006-01-02"), *r.AgeDays)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Source, r.Version, released,
+			orDash(r.LatestPatch), orDash(r.LatestMinor), orDash(r.Latest), statusColor(r.Status)(r.Status))
+	}
+	_ = w.Flush()
+}
+
+// orDash returns s, or "-" when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+func TestNewOutdatedReport(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 8, d, 0, 0, 0, 0, time.UTC) }
+	releases := []config.Release{
+		{Version: "4.1.0-alpha.1", Prerelease: true},
+		{Version: "4.0.0", PublishedAt: day(20)},
+		{Version: "3.78.1", PublishedAt: day(10)},
+		{Version: "3.78.0", PublishedAt: day(3)},
+		{Version: "3.77.0", PublishedAt: day(1)},
+	}
+	tests := []struct {
+		version, patch, minor, status string
+	}{
+		{"4.0.0", "4.0.0", "4.0.0", "up-to-date"},
+		{"3.78.1", "3.78.1", "3.78.1", "major"},
+		{"3.77.0", "3.77.0", "3.78.1", "major"},
+	}
+	for _, tc := range tests {
+		r := newOutdatedReport("active", tc.version, releases, day(31))
+		if r.LatestPatch != tc.patch || r.LatestMinor != tc.minor || r.Latest != "4.0.0" || r.Status != tc.status {
+			t.Errorf("newOutdatedReport(%s) = %+v; want patch %s, minor %s, status %s", tc.version, r, tc.patch, tc.minor, tc.status)
+		}
+	}
+
+	releases = releases[2:]
+	for version, status := range map[string]string{"3.78.0": "patch", "3.77.0": "minor"} {
+		if r := newOutdatedReport("active", version, releases, day(31)); r.Status != status {
+			t.Errorf("status of %s = %s; want %s", version, r.Status, status)
+		}
+	}
+	if r := newOutdatedReport("active", "3.77.0", releases, day(31)); r.AgeDays == nil || *r.AgeDays != 30 {
+		t.Errorf("expected 3.77.0 to be 30 days old, got %+v", r)
+	}
+}
+
+func TestOutdatedCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	defer mockVersionOperations(t)()
+
+	versionDir := filepath.Join(tmpDir, "versions", "3.77.0")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(versionDir, "pulumi"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := pvm.New(layoutOptions(pvm.WithTool(config.Pulumi))...).Use("3.77.0"); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, config.PinFile), []byte("3.78.0\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	defer func() {
+		_ = outdatedCmd.Flags().Set("json", "false")
+		_ = outdatedCmd.Flags().Set("fail-if-older-than", "")
+	}()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"outdated", "--json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var reports []outdatedReport
+	if err := json.Unmarshal(buf.Bytes(), &reports); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected the active and the pinned version, got %+v", reports)
+	}
+	if r := reports[0]; r.Source != "active" || r.Version != "3.77.0" || r.LatestMinor != "3.78.1" || r.Status != "minor" {
+		t.Errorf("unexpected report for the active version: %+v", r)
+	}
+	if r := reports[1]; r.Source != config.PinFile || r.Version != "3.78.0" || r.LatestPatch != "3.78.1" || r.Status != "patch" {
+		t.Errorf("unexpected report for the pinned version: %+v", r)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"outdated", "--json=false", "--fail-if-older-than", "90d"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "3.77.0 (active, released 2023-08-01)") {
+		t.Errorf("expected an error naming the old versions, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "LATEST PATCH") {
+		t.Errorf("expected a table, got: %s", buf.String())
+	}
+
+	rootCmd.SetArgs([]string{"outdated", "--fail-if-older-than", "0d"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "must be longer than 0") {
+		t.Errorf("expected a zero threshold to be rejected, got %v", err)
+	}
+
+	// A version without a known release date cannot pass the check.
+	if err := os.WriteFile(filepath.Join(project, config.PinFile), []byte("3.77.5\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	rootCmd.SetArgs([]string{"outdated", "--fail-if-older-than", "36500d"})
+	err = rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "unknown release date: 3.77.5 ("+config.PinFile+")") {
+		t.Errorf("expected an error naming the undated version, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage Pulumi resource provider plugins",
+	Long: `Install, list, remove and switch Pulumi resource provider plugins.
+
+Plugins are downloaded from the provider's GitHub releases, verified against
+the checksums published with the release, into the pvm plugin store and
+linked into Pulumi's plugin directory (~/.pulumi/plugins). A set of plugin
+versions can be associated with a Pulumi CLI version with 'pvm plugin use';
+'pvm use' then switches the linked plugins along with the CLI.`,
+}
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <name> [version]",
+	Short: "Install a resource provider plugin",
+	Long:  "Install a resource provider plugin, e.g. 'pvm plugin install aws 6.0.0'. The version defaults to the latest release.",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version := "latest"
+		if len(args) == 2 {
+			version = args[1]
+		}
+
+		plugin, err := utils.InstallPlugin(cmd.Context(), args[0], version)
+		if err != nil {
+			return err
+		}
+		if err := utils.LinkPlugin(plugin); err != nil {
+			return fmt.Errorf("failed to link plugin %s: %w", plugin, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed plugin"), plugin)
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%s pvm plugin use %s %s\n", utils.Info("To keep it with the current Pulumi version, run:"), plugin.Name, plugin.Version)
+		return nil
+	},
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed plugins",
+	Long:  "List the resource provider plugins installed by pvm.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugins, err := utils.GetInstalledPlugins()
+		if err != nil {
+			return fmt.Errorf("failed to list plugins: %v", err)
+		}
+
+		if len(plugins) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), utils.Warning("No plugins installed. Use 'pvm plugin install <name> [version]' to install one."))
+			return nil
+		}
+
+		current, err := newManager(config.Pulumi).Current()
+		if err != nil {
+			return fmt.Errorf("failed to get current version: %v", err)
+		}
+		sets, err := utils.LoadPluginSets()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), utils.Info("Installed plugins:"))
+		for _, plugin := range plugins {
+			prefix := "  "
+			if utils.IsPluginLinked(plugin) {
+				prefix = utils.Current("→ ")
+			}
+			suffix := ""
+			if current != "" && sets[current][plugin.Name] == plugin.Version {
+				suffix = utils.Success(fmt.Sprintf(" (used with Pulumi %s)", current))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s%s %s%s\n", prefix, plugin.Name, plugin.Version, suffix)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), utils.Info("\nLegend:"))
+		fmt.Fprintln(cmd.OutOrStdout(), utils.Current("  →  active"))
+		return nil
+	},
+}
+
+var pluginRemoveCmd = &cobra.Command{
+	Use:   "remove <name> <version>",
+	Short: "Remove an installed plugin",
+	Long:  "Remove an installed resource provider plugin and drop it from every plugin set.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugin, err := utils.ParsePlugin(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		if err := utils.RemovePlugin(plugin); err != nil {
+			return fmt.Errorf("failed to remove plugin %s: %w", plugin, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Successfully removed plugin %s\n", plugin)
+		return nil
+	},
+}
+
+var pluginUseCmd = &cobra.Command{
+	Use:   "use <name> <version>",
+	Short: "Associate a plugin version with a Pulumi version",
+	Long: `Add a plugin version to the plugin set of a Pulumi CLI version (the current
+one by default) and activate it. Whenever 'pvm use' switches to that Pulumi
+version, the plugins in its set are switched too.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cliVersion, _ := cmd.Flags().GetString("for")
+		installIfMissing, _ := cmd.Flags().GetBool("install")
+		plugin, err := utils.ParsePlugin(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		m := newManager(config.Pulumi)
+		current, err := m.Current()
+		if err != nil {
+			return fmt.Errorf("failed to get current version: %v", err)
+		}
+		if cliVersion == "" {
+			if current == "" {
+				return fmt.Errorf("no Pulumi version currently selected. Use 'pvm use <version>' or pass --for")
+			}
+			cliVersion = current
+		} else if cliVersion, err = m.ResolveInstalled(cliVersion); err != nil {
+			return fmt.Errorf("cannot associate plugin %s with Pulumi: %w", plugin, err)
+		}
+
+		installed, err := utils.GetInstalledPlugins()
+		if err != nil {
+			return fmt.Errorf("failed to list plugins: %v", err)
+		}
+		found := false
+		for _, p := range installed {
+			if p == plugin {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if !installIfMissing {
+				return fmt.Errorf("plugin %s is not installed. Use 'pvm plugin install %s %s' first or retry with --install flag", plugin, plugin.Name, plugin.Version)
+			}
+			if _, err := utils.InstallPlugin(cmd.Context(), plugin.Name, plugin.Version); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed plugin"), plugin)
+		}
+
+		if err := utils.AddPluginToSet(cliVersion, plugin); err != nil {
+			return fmt.Errorf("failed to update plugin set: %w", err)
+		}
+
+		if cliVersion == current {
+			if err := utils.LinkPlugin(plugin); err != nil {
+				return fmt.Errorf("failed to switch to plugin %s: %w", plugin, err)
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s with Pulumi %s\n", utils.Success("Using plugin"), plugin, cliVersion)
+		return nil
+	},
+}
+
+func init() {
+	pluginUseCmd.Flags().String("for", "", "Installed Pulumi version to associate the plugin with (defaults to the current version)")
+	pluginUseCmd.Flags().Bool("install", false, "Install the plugin if not already installed")
+
+	pluginCmd.AddCommand(pluginInstallCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+	pluginCmd.AddCommand(pluginRemoveCmd)
+	pluginCmd.AddCommand(pluginUseCmd)
+}
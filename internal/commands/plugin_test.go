@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+func TestPluginListEmpty(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"plugin", "list"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "No plugins installed") {
+		t.Errorf("expected no-plugins message, got: %s", buf.String())
+	}
+}
+
+func TestPluginInstallCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+	cleanup := utils.MockPluginOperations(t)
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"plugin", "install", "aws", "6.0.0"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Successfully installed plugin aws@6.0.0") {
+		t.Errorf("expected success message, got: %s", out)
+	}
+	if !strings.Contains(out, "pvm plugin use aws 6.0.0") {
+		t.Errorf("expected use hint, got: %s", out)
+	}
+	if !utils.IsPluginLinked(utils.Plugin{Name: "aws", Version: "6.0.0"}) {
+		t.Error("expected the installed plugin to be linked")
+	}
+}
+
+func TestPluginUseCommand(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.78.1", "pulumi")
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+	if err := os.MkdirAll(filepath.Join(tmpDir, "plugins", "resource-aws-v6.0.0"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"plugin", "use", "aws", "6.0.0"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Using plugin aws@6.0.0 with Pulumi 3.78.1") {
+		t.Errorf("expected use message, got: %s", buf.String())
+	}
+	sets, err := utils.LoadPluginSets()
+	if err != nil {
+		t.Fatalf("LoadPluginSets: %v", err)
+	}
+	if sets["3.78.1"]["aws"] != "6.0.0" {
+		t.Errorf("expected aws 6.0.0 in the 3.78.1 plugin set, got %v", sets)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"plugin", "list"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "aws 6.0.0 (used with Pulumi 3.78.1)") {
+		t.Errorf("expected plugin in list output, got: %s", buf.String())
+	}
+}
+
+func TestPluginUseCommandFor(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.90.0", "pulumi")
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	cleanup := utils.MockPluginOperations(t)
+	defer cleanup()
+	defer func() {
+		_ = pluginUseCmd.Flags().Set("for", "")
+		_ = pluginUseCmd.Flags().Set("install", "false")
+	}()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"plugin", "use", "aws", "6.0.0", "--install", "--for", "3.78"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sets, err := utils.LoadPluginSets()
+	if err != nil {
+		t.Fatalf("LoadPluginSets: %v", err)
+	}
+	if sets["3.78.1"]["aws"] != "6.0.0" {
+		t.Errorf("expected --for 3.78 to resolve to the installed 3.78.1, got %v", sets)
+	}
+	if utils.IsPluginLinked(utils.Plugin{Name: "aws", Version: "6.0.0"}) {
+		t.Error("expected a plugin for another Pulumi version not to be linked")
+	}
+
+	rootCmd.SetArgs([]string{"plugin", "use", "aws", "6.0.0", "--for", "3.50.0"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected an error for a Pulumi version that is not installed, got %v", err)
+	}
+}
+
+func TestPluginUseCommandNotInstalled(t *testing.T) {
+	setupActiveVersion(t, "3.78.1", "pulumi")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"plugin", "use", "aws", "9.9.9"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for plugin that is not installed, got nil")
+	}
+}
+
+func TestPluginRemoveCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "plugins", "resource-aws-v6.0.0"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"plugin", "remove", "aws", "6.0.0"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Successfully removed plugin aws@6.0.0") {
+		t.Errorf("expected success message, got: %s", buf.String())
+	}
+}
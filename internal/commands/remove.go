@@ -4,22 +4,36 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/internal/config"
 )
 
+func init() {
+	addPlatformFlags(removeCmd)
+}
+
 var removeCmd = &cobra.Command{
 	Use:   "remove <version>",
 	Short: "Remove a specific version of Pulumi",
-	Long:  "Remove a specific version of Pulumi that has been installed.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Remove a specific version of Pulumi that has been installed. Use --os and
+--arch to remove a build installed for another platform.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRemovableVersions,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		version := args[0]
+		tool, version, err := config.ParseToolVersion(args[0])
+		if err != nil {
+			return err
+		}
+
+		platformOpts, err := platformOptions(cmd)
+		if err != nil {
+			return err
+		}
 
-		if err := utils.RemoveVersion(version); err != nil {
+		if err := newManager(tool, platformOpts...).Remove(version); err != nil {
 			return fmt.Errorf("failed to remove version %s: %w", version, err)
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Successfully removed Pulumi %s\n", version)
+		fmt.Fprintf(cmd.OutOrStdout(), "Successfully removed %s %s\n", tool.DisplayName, version)
 		return nil
 	},
 }
@@ -1,7 +1,13 @@
 package commands
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
 	"github.com/tomski747/pvm/internal/utils"
 )
 
@@ -16,28 +22,57 @@ Examples:
   pvm use 3.78.1        Switch to Pulumi version 3.78.1
   pvm list              List installed versions
   pvm list --all        List all available versions
-  pvm current           Show current version`,
+  pvm current           Show current version
+  pvm doctor            Diagnose environment problems
+  pvm completion bash   Generate a shell completion script`,
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Help()
 	},
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	// Cancel downloads and extraction on Ctrl-C or SIGTERM so partially
+	// installed versions are cleaned up instead of left half-written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable color output")
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		noColor, _ := cmd.Flags().GetBool("no-color")
+		if !noColor {
+			if color, err := config.GetBool("color"); err == nil && !color {
+				noColor = true
+			}
+		}
 		if noColor {
 			utils.DisableColors()
 		}
 	}
 
 	rootCmd.AddCommand(installCmd())
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(mirrorCmd)
 	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(changelogCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(currentCmd)
 	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(whichCmd)
+	rootCmd.AddCommand(whereCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(pluginCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(selfUpdateCmd)
+	rootCmd.AddCommand(implodeCmd)
 }
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+// updateCheckTimeout bounds the release lookup of 'pvm version', which must
+// stay fast when GitHub is slow or unreachable.
+const updateCheckTimeout = 2 * time.Second
+
+// selfManager returns the manager for pvm's own releases. They always come
+// from GitHub, whatever the release source of the tools, and are cached for
+// a day. Without a usable GitHub token they are fetched anonymously. Tests
+// replace it.
+var selfManager = func() *pvm.Manager {
+	token := func() (string, error) {
+		token, _ := utils.GitHubToken()
+		return token, nil
+	}
+	return pvm.New(layoutOptions(
+		pvm.WithTool(config.PVM),
+		pvm.WithReleaseSource(pvm.NewGitHubSource(utils.LazyGitHubClient(token))),
+		pvm.WithCacheTTL(config.CacheTTL),
+	)...)
+}
+
+// selfExecutable returns the path of the running pvm binary. Tests replace
+// it.
+var selfExecutable = os.Executable
+
+func init() {
+	selfUpdateCmd.Flags().Bool("check", false, "Only report whether a newer version is available")
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update pvm to the latest release",
+	Long: `Download the latest pvm release for this platform from GitHub, verify its
+checksum and replace the running pvm binary with it. With --check, only
+report whether a newer version is available.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checkOnly, _ := cmd.Flags().GetBool("check")
+
+		m := selfManager()
+		latest, err := m.Latest(cmd.Context(), true)
+		if err != nil {
+			return fmt.Errorf("failed to get the latest pvm release: %v", err)
+		}
+		current := strings.TrimPrefix(config.Version, "v")
+		if !isReleaseVersion(current) {
+			if checkOnly {
+				fmt.Fprintf(cmd.OutOrStdout(), "pvm %s is a development build; the latest release is %s\n", config.Version, latest)
+				return nil
+			}
+			return fmt.Errorf("pvm %s is a development build; install a release from https://github.com/%s/releases instead", config.Version, config.PVM.Repo)
+		}
+		if !utils.SemverGreater(latest, current) {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s is up to date\n", utils.Success("pvm"), current)
+			return nil
+		}
+		if checkOnly {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is available (current: %s). Run 'pvm self-update' to install it.\n", utils.Warning("pvm "+latest), current)
+			return nil
+		}
+
+		exe, err := selfExecutable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the pvm binary: %v", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		if err := m.ReplaceExecutable(cmd.Context(), latest, exe); err != nil {
+			return fmt.Errorf("failed to update pvm: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s -> %s\n", utils.Success("Updated pvm"), current, latest)
+		return nil
+	},
+}
+
+// isReleaseVersion reports whether v looks like a released version rather
+// than a development build such as "dev".
+func isReleaseVersion(v string) bool {
+	major, _, _ := strings.Cut(v, ".")
+	return major != "" && strings.Trim(major, "0123456789") == ""
+}
+
+// newerPVMRelease returns the latest pvm release if it is newer than the
+// running one, or an empty string. It gives up quietly on errors, for
+// development builds and unless the update_check setting is on. GitHub is
+// asked at most once a day: each attempt is recorded, even a failed one, and
+// until a day has passed only the cached release list is consulted.
+func newerPVMRelease(ctx context.Context) string {
+	current := strings.TrimPrefix(config.Version, "v")
+	if !isReleaseVersion(current) {
+		return ""
+	}
+	if enabled, err := config.GetBool("update_check"); err != nil || !enabled {
+		return ""
+	}
+
+	m := selfManager()
+	var latest string
+	stamp := filepath.Join(m.CacheDir(), config.UpdateCheckFile)
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < config.CacheTTL {
+		versions, _ := m.CachedVersions()
+		latest = newestStable(versions)
+	} else {
+		if err := os.MkdirAll(m.CacheDir(), 0755); err == nil {
+			_ = os.WriteFile(stamp, []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0644)
+		}
+		ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+		defer cancel()
+		latest, _ = m.Latest(ctx, false)
+	}
+
+	if latest == "" || !utils.SemverGreater(latest, current) {
+		return ""
+	}
+	return latest
+}
+
+// newestStable returns the newest version in versions that is not a
+// pre-release, or an empty string.
+func newestStable(versions []string) string {
+	newest := ""
+	for _, v := range versions {
+		if !utils.IsPrerelease(v) && (newest == "" || utils.SemverGreater(v, newest)) {
+			newest = v
+		}
+	}
+	return newest
+}
@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/pkg/pvm"
+)
+
+// usePVMReleases serves pvm 1.0.0 and 1.1.0 releases to selfManager, makes
+// the running pvm 1.0.0 and returns the path standing in for its binary.
+func usePVMReleases(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("release archives are zip files on Windows")
+	}
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	content := "#!/bin/sh\necho v1.1.0"
+	if err := tw.WriteHeader(&tar.Header{Name: "pvm", Mode: 0755, Size: int64(len(content))}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	archive := buf.Bytes()
+
+	goos, arch := config.GetPlatformInfo()
+	asset := config.PVM.AssetName("1.1.0", goos, arch)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/tomski747/pvm/releases":
+			_ = json.NewEncoder(w).Encode([]map[string]string{{"tag_name": "v1.1.0"}, {"tag_name": "v1.0.0"}})
+		case "/tomski747/pvm/releases/download/v1.1.0/" + asset:
+			_, _ = w.Write(archive)
+		case "/tomski747/pvm/releases/download/v1.1.0/" + config.PVM.ChecksumsName("1.1.0"):
+			fmt.Fprintf(w, "%x  %s\n", sha256.Sum256(archive), asset)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	root := t.TempDir()
+	origSelfManager, origExecutable, origVersion := selfManager, selfExecutable, config.Version
+	selfManager = func() *pvm.Manager {
+		return pvm.New(pvm.WithTool(config.PVM), pvm.WithRoot(root), pvm.WithReleaseSource(pvm.NewMirrorSource(server.URL, nil)))
+	}
+	exe := filepath.Join(t.TempDir(), "pvm")
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	selfExecutable = func() (string, error) { return exe, nil }
+	config.Version = "v1.0.0"
+	t.Cleanup(func() {
+		selfManager, selfExecutable, config.Version = origSelfManager, origExecutable, origVersion
+	})
+	return exe
+}
+
+func TestSelfUpdateCommand(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	exe := usePVMReleases(t)
+	defer func() { _ = selfUpdateCmd.Flags().Set("check", "false") }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"self-update", "--check"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "pvm 1.1.0 is available") {
+		t.Errorf("expected an update to be reported, got: %s", buf.String())
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old" {
+		t.Error("expected --check to leave the binary alone")
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"self-update", "--check=false"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Updated pvm 1.0.0 -> 1.1.0") {
+		t.Errorf("expected an update message, got: %s", buf.String())
+	}
+	if data, _ := os.ReadFile(exe); !strings.Contains(string(data), "v1.1.0") {
+		t.Errorf("expected the binary to be replaced, got %q", data)
+	}
+
+	config.Version = "dev"
+	rootCmd.SetArgs([]string{"self-update"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "development build") {
+		t.Errorf("expected development builds to be refused, got %v", err)
+	}
+}
+
+func TestVersionCommandMentionsUpdate(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	usePVMReleases(t)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"version"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "available") {
+		t.Errorf("expected no update check by default, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	t.Setenv("PVM_UPDATE_CHECK", "true")
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "pvm 1.1.0 is available") {
+		t.Errorf("expected a newer release to be mentioned, got: %s", buf.String())
+	}
+}
+
+func TestUpdateCheckRecordsFailedAttempts(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+	t.Setenv("PVM_UPDATE_CHECK", "true")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	root := t.TempDir()
+	origSelfManager, origVersion := selfManager, config.Version
+	selfManager = func() *pvm.Manager {
+		return pvm.New(pvm.WithTool(config.PVM), pvm.WithRoot(root), pvm.WithReleaseSource(pvm.NewMirrorSource(server.URL, nil)))
+	}
+	config.Version = "v1.0.0"
+	defer func() { selfManager, config.Version = origSelfManager, origVersion }()
+
+	for i := 0; i < 2; i++ {
+		if latest := newerPVMRelease(context.Background()); latest != "" {
+			t.Errorf("expected no release from a failing source, got %s", latest)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the failed check not to be retried within a day, got %d requests", requests)
+	}
+
+	// Once a day has passed, it is tried again.
+	old := time.Now().Add(-2 * config.CacheTTL)
+	if err := os.Chtimes(filepath.Join(root, config.UpdateCheckFile), old, old); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	newerPVMRelease(context.Background())
+	if requests != 2 {
+		t.Errorf("expected a new check after a day, got %d requests", requests)
+	}
+}
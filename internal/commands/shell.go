@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var initCmd = &cobra.Command{
+	Use:       "init <bash|zsh|fish>",
+	Short:     "Print shell integration code",
+	ValidArgs: utils.SupportedShells,
+	Long: `Print shell code that puts the pvm bin directory on PATH and switches
+Pulumi versions automatically when entering a directory with a .pulumi-version
+pin file. Add it to your shell profile:
+
+  bash:  echo 'eval "$(pvm init bash)"' >> ~/.bashrc
+  zsh:   echo 'eval "$(pvm init zsh)"' >> ~/.zshrc
+  fish:  echo 'pvm init fish | source' >> ~/.config/fish/config.fish`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noHook, _ := cmd.Flags().GetBool("no-hook")
+
+		script, err := utils.ShellInitScript(args[0], !noHook)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), script)
+		return nil
+	},
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env [version]",
+	Short: "Print shell code to use a version in the current shell only",
+	Long: `Print shell code that puts the given version first on PATH for the
+current shell session only:
+
+  eval "$(pvm env 3.78.1)"
+
+Without a version, the version pinned by the closest .pulumi-version file is
+used; outside a pinned project the globally selected version is restored.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeInstalledVersions,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell, _ := cmd.Flags().GetString("shell")
+		if shell == "" {
+			shell = utils.DetectShell()
+		}
+
+		var version string
+		if len(args) == 1 {
+			version = args[0]
+		} else {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %v", err)
+			}
+			pinned, pinFile, err := utils.GetPinnedVersion(cwd)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", pinFile, err)
+			}
+			version = pinned
+		}
+
+		var versionDir string
+		if version != "" {
+			m := newManager(config.Pulumi)
+			resolved, err := m.ResolveInstalled(version)
+			if err != nil {
+				return fmt.Errorf("%v. Use 'pvm install %s' first", err, version)
+			}
+			if versionDir, err = m.VersionDir(resolved); err != nil {
+				return err
+			}
+			version = resolved
+		}
+
+		script, err := utils.ShellEnvScript(shell, version, versionDir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), script)
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().Bool("no-hook", false, "Only set up PATH, without switching versions on directory change")
+	envCmd.Flags().String("shell", "", "Shell to print code for (bash, zsh or fish; defaults to $SHELL)")
+}
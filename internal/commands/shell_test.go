@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestInitCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"init", "zsh"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, filepath.Join(tmpDir, "bin")) {
+		t.Errorf("expected bin path in output, got: %s", out)
+	}
+	if !strings.Contains(out, "add-zsh-hook chpwd _pvm_hook") {
+		t.Errorf("expected chpwd hook in output, got: %s", out)
+	}
+}
+
+func TestInitCommandUnsupportedShell(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"init", "tcsh"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for unsupported shell, got nil")
+	}
+}
+
+func TestEnvCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"env", "--shell", "bash", "3.78"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "export PATH='"+filepath.Join(tmpDir, "versions", "3.78.1")) {
+		t.Errorf("expected version dir first on PATH, got: %s", out)
+	}
+	if !strings.Contains(out, "export PVM_VERSION='3.78.1'") {
+		t.Errorf("expected PVM_VERSION export, got: %s", out)
+	}
+}
+
+func TestEnvCommandPinnedVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, config.PinFile), []byte("3.78.1\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"env", "--shell", "fish"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "set -gx PVM_VERSION '3.78.1'") {
+		t.Errorf("expected pinned version in output, got: %s", buf.String())
+	}
+}
+
+func TestEnvCommandNotInstalled(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"env", "--shell", "bash", "9.9.9"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for non-installed version, got nil")
+	}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade to the newest release within a scope",
+	Long: `Install the newest stable release newer than the active version and switch to
+it. The scope limits how far to go:
+
+  --patch   newest patch of the active minor version (3.78.0 -> 3.78.2)
+  --minor   newest minor version of the active major version (the default)
+  --major   newest release overall
+
+The release list is refreshed first so the newest release is found even when
+the cache is fresh; pass --refresh=false to use the cached list, e.g. offline.
+With --prune-old, the previously active version is removed afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		toolName, _ := cmd.Flags().GetString("tool")
+		tool, err := config.LookupTool(toolName)
+		if err != nil {
+			return err
+		}
+		scope := "minor"
+		for _, s := range []string{"patch", "major"} {
+			if set, _ := cmd.Flags().GetBool(s); set {
+				scope = s
+			}
+		}
+		pruneOld, _ := cmd.Flags().GetBool("prune-old")
+		refresh, _ := cmd.Flags().GetBool("refresh")
+
+		m := newManager(tool)
+		current, err := m.Current()
+		if err != nil {
+			return fmt.Errorf("failed to get current version: %v", err)
+		}
+		if current == "" {
+			return fmt.Errorf("no active %s version; run 'pvm use %s' first", tool.DisplayName, tool.Spec("<version>"))
+		}
+
+		versions, err := m.Available(cmd.Context(), refresh)
+		if err != nil {
+			return fmt.Errorf("failed to fetch available versions: %v", err)
+		}
+		target, err := upgradeTarget(current, versions, scope)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s is up to date (--%s)\n", utils.Success(tool.DisplayName), current, scope)
+			return nil
+		}
+
+		if err := m.Install(cmd.Context(), target); err != nil {
+			return fmt.Errorf("failed to install version %s: %w", target, err)
+		}
+		if err := switchVersion(cmd.Context(), m, target); err != nil {
+			return fmt.Errorf("failed to switch to version %s: %w", target, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s -> %s\n", utils.Success("Upgraded "+tool.DisplayName), current, target)
+
+		if pruneOld {
+			if err := m.Remove(current); err != nil {
+				return fmt.Errorf("failed to remove version %s: %w", current, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %s %s\n", tool.DisplayName, current)
+		}
+		return nil
+	},
+}
+
+// upgradeTarget returns the newest stable version in versions that is newer
+// than current within scope ("patch", "minor" or "major"), or an empty
+// string when current is already the newest.
+func upgradeTarget(current string, versions []string, scope string) (string, error) {
+	var stable []string
+	for _, v := range versions {
+		if !utils.IsPrerelease(v) {
+			stable = append(stable, v)
+		}
+	}
+
+	parts := strings.Split(current, ".")
+	prefix := ""
+	switch scope {
+	case "patch":
+		if len(parts) < 2 {
+			return "", fmt.Errorf("cannot determine the minor version of %s", current)
+		}
+		prefix = parts[0] + "." + parts[1]
+	case "minor":
+		prefix = parts[0]
+	}
+
+	var newest string
+	if prefix == "" {
+		for _, v := range stable {
+			if newest == "" || utils.SemverGreater(v, newest) {
+				newest = v
+			}
+		}
+	} else {
+		newest, _ = utils.FindLatestMatchingVersion(prefix, stable)
+	}
+	if newest == "" || !utils.SemverGreater(newest, current) {
+		return "", nil
+	}
+	return newest, nil
+}
+
+func init() {
+	upgradeCmd.Flags().Bool("patch", false, "Only upgrade to a newer patch release")
+	upgradeCmd.Flags().Bool("minor", false, "Upgrade to the newest release of the active major version (default)")
+	upgradeCmd.Flags().Bool("major", false, "Upgrade to the newest release")
+	upgradeCmd.MarkFlagsMutuallyExclusive("patch", "minor", "major")
+	upgradeCmd.Flags().Bool("prune-old", false, "Remove the previously active version after upgrading")
+	upgradeCmd.Flags().Bool("refresh", true, "Refresh the release list before choosing the target")
+	upgradeCmd.Flags().String("tool", config.Pulumi.Name, "Tool to upgrade (e.g. esc)")
+}
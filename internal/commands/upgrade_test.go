@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestUpgradeTarget(t *testing.T) {
+	versions := []string{"4.0.0", "3.79.0-alpha.1", "3.78.2", "3.78.1", "3.78.0", "3.77.0"}
+	tests := []struct {
+		current, scope, want string
+	}{
+		{"3.78.0", "patch", "3.78.2"},
+		{"3.78.2", "patch", ""},
+		{"3.77.0", "minor", "3.78.2"},
+		{"3.77.0", "major", "4.0.0"},
+		{"4.0.0", "major", ""},
+	}
+	for _, tc := range tests {
+		got, err := upgradeTarget(tc.current, versions, tc.scope)
+		if err != nil || got != tc.want {
+			t.Errorf("upgradeTarget(%s, %s) = %q, %v; want %q", tc.current, tc.scope, got, err, tc.want)
+		}
+	}
+}
+
+func TestUpgradeCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+	releaseDir := filepath.Dir(useReleaseDir(t))
+
+	// A fresh cache from before 3.78.1 was released must not hide it.
+	cache, _ := json.Marshal(config.ReleaseCache{
+		Versions:       []string{"3.77.0"},
+		Timestamp:      time.Now(),
+		SourceKind:     "dir",
+		SourceLocation: releaseDir,
+	})
+	if err := os.WriteFile(config.Pulumi.CachePath(tmpDir), cache, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// Activate an older version by hand.
+	oldDir := filepath.Join(tmpDir, "versions", "3.77.0")
+	if err := os.MkdirAll(oldDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(oldDir, "pulumi"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := newManager(config.Pulumi).Use("3.77.0"); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	defer func() { _ = upgradeCmd.Flags().Set("prune-old", "false") }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"upgrade", "--prune-old"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Upgraded Pulumi 3.77.0 -> 3.78.1") {
+		t.Errorf("expected an upgrade message, got: %s", buf.String())
+	}
+	if current, _ := newManager(config.Pulumi).Current(); current != "3.78.1" {
+		t.Errorf("expected 3.78.1 to be active, got %q", current)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Error("expected --prune-old to remove 3.77.0")
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"upgrade"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "3.78.1 is up to date") {
+		t.Errorf("expected an up-to-date message, got: %s", buf.String())
+	}
+}
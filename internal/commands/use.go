@@ -4,52 +4,77 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
 	"github.com/tomski747/pvm/internal/utils"
 )
 
 var useCmd = &cobra.Command{
-	Use:   "use <version>",
+	Use:   "use [version]",
 	Short: "Switch to a specific version of Pulumi",
-	Long:  "Switch to a specific version of Pulumi. Use 'latest' to switch to the most recent version.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Switch to a specific version of Pulumi. Use 'latest' to switch to the most
+recent version. Without a version, the version pinned by the closest
+.pulumi-version file or the default_version setting is used.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeInstalledVersions,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		version := args[0]
+		spec, err := versionArg(args)
+		if err != nil {
+			return err
+		}
+		tool, version, err := config.ParseToolVersion(spec)
+		if err != nil {
+			return err
+		}
 		installIfMissing, _ := cmd.Flags().GetBool("install")
-
-		if version == "latest" {
-			latest, err := utils.GetLatestVersion()
-			if err != nil {
-				return fmt.Errorf("failed to get latest version: %w", err)
+		if !cmd.Flags().Changed("install") {
+			if installIfMissing, err = config.GetBool("auto_install"); err != nil {
+				return err
 			}
-			version = latest
 		}
+		m := newManager(tool)
 
-		resolvedVersion, err := utils.ResolveVersion(version)
+		resolvedVersion, err := m.Resolve(cmd.Context(), version)
 		if err != nil {
 			return fmt.Errorf("failed to resolve version: %w", err)
 		}
 
-		installed := utils.GetInstalledVersions()
+		installed, err := installedSet(m)
+		if err != nil {
+			return fmt.Errorf("failed to list installed versions: %v", err)
+		}
 		if !installed[resolvedVersion] {
 			if !installIfMissing {
-				return fmt.Errorf("version %s is not installed. Use 'pvm install %s' first or retry with --install flag", resolvedVersion, resolvedVersion)
+				return fmt.Errorf("version %s is not installed. Use 'pvm install %s' first or retry with --install flag", resolvedVersion, tool.Spec(resolvedVersion))
 			}
 
-			if err := utils.InstallVersion(resolvedVersion); err != nil {
+			if err := m.Install(cmd.Context(), resolvedVersion); err != nil {
 				return fmt.Errorf("failed to install version %s: %w", resolvedVersion, err)
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed Pulumi"), resolvedVersion)
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Successfully installed "+tool.DisplayName), resolvedVersion)
 		}
 
-		if err := utils.UseVersion(resolvedVersion); err != nil {
+		if err := switchVersion(cmd.Context(), m, resolvedVersion); err != nil {
 			return fmt.Errorf("failed to switch to version %s: %w", resolvedVersion, err)
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Switched to Pulumi"), resolvedVersion)
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", utils.Success("Switched to "+tool.DisplayName), resolvedVersion)
+		warnIfShadowed(cmd, tool)
 		return nil
 	},
 }
 
+// warnIfShadowed warns when running tool's binary from the shell would not
+// run the version pvm just switched to: the bin directory is not on PATH or
+// another installation comes first.
+func warnIfShadowed(cmd *cobra.Command, tool config.Tool) {
+	result := utils.CheckBinOnPath(tool.Binary)
+	if result.Status == utils.CheckPass {
+		return
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), utils.Warning(fmt.Sprintf("Warning: %s, so '%s' will not run this version.", result.Message, tool.Binary)))
+	fmt.Fprintf(cmd.ErrOrStderr(), "To fix it, %s.\n", result.Hint)
+}
+
 func init() {
-	useCmd.Flags().Bool("install", false, "Install the version if not already installed")
+	useCmd.Flags().Bool("install", false, "Install the version if not already installed (default from the auto_install setting)")
 }
@@ -8,7 +8,6 @@ import (
 	"testing"
 
 	"github.com/tomski747/pvm/internal/config"
-	"github.com/tomski747/pvm/internal/utils"
 )
 
 func TestUseCommand(t *testing.T) {
@@ -20,7 +19,7 @@ func TestUseCommand(t *testing.T) {
 	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
 	defer config.ResetConfig()
 
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -42,7 +41,7 @@ func TestUseCommandNotInstalled(t *testing.T) {
 	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
 	defer config.ResetConfig()
 
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -60,7 +59,7 @@ func TestUseCommandWithInstallFlag(t *testing.T) {
 	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
 	defer config.ResetConfig()
 
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -81,6 +80,32 @@ func TestUseCommandWithInstallFlag(t *testing.T) {
 	}
 }
 
+func TestUseCommandAutoInstall(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	t.Setenv("PVM_AUTO_INSTALL", "true")
+
+	cleanup := mockVersionOperations(t)
+	defer cleanup()
+
+	// Flags keep their state between Execute calls.
+	_ = useCmd.Flags().Set("install", "false")
+	useCmd.Flags().Lookup("install").Changed = false
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"use", "3.78.1"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Successfully installed Pulumi") {
+		t.Errorf("expected auto_install to install the version, got: %s", buf.String())
+	}
+}
+
 func TestUseCommandLatest(t *testing.T) {
 	tmpDir := t.TempDir()
 	// Mock GetLatestVersion returns "3.78.1"; pre-install it
@@ -90,7 +115,7 @@ func TestUseCommandLatest(t *testing.T) {
 	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
 	defer config.ResetConfig()
 
-	cleanup := utils.MockVersionOperations(t)
+	cleanup := mockVersionOperations(t)
 	defer cleanup()
 
 	buf := new(bytes.Buffer)
@@ -106,3 +131,49 @@ func TestUseCommandLatest(t *testing.T) {
 		t.Errorf("expected version in output, got: %s", buf.String())
 	}
 }
+
+func TestUseCommandWarnsAboutShadowing(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.78.1"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	defer config.ResetConfig()
+	defer mockVersionOperations(t)()
+
+	// fakeManager does not link binaries; stand in for the pulumi symlink.
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, executableName(config.Pulumi)), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	otherDir := t.TempDir()
+	other := filepath.Join(otherDir, executableName(config.Pulumi))
+	if err := os.WriteFile(other, []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PATH", otherDir+string(os.PathListSeparator)+binDir)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"use", "3.78.1"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), other+" shadows the pvm-managed pulumi") {
+		t.Errorf("expected a shadowing warning naming %s, got: %s", other, buf.String())
+	}
+
+	// With pvm's bin directory first, there is nothing to warn about.
+	buf.Reset()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+otherDir)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "Warning") {
+		t.Errorf("expected no warning, got: %s", buf.String())
+	}
+}
@@ -5,14 +5,19 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
 )
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print pvm version",
-	Long:  `Print the version information of pvm`,
+	Long: `Print the version information of pvm. With update_check set to true, a
+newer pvm release is mentioned when one is available.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Fprintln(cmd.OutOrStdout(), config.Version)
+		if latest := newerPVMRelease(cmd.Context()); latest != "" {
+			fmt.Fprintln(cmd.ErrOrStderr(), utils.Info(fmt.Sprintf("pvm %s is available; run 'pvm self-update' to install it.", latest)))
+		}
 	},
 }
 
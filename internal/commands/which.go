@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+var whichCmd = &cobra.Command{
+	Use:   "which [binary]",
+	Short: "Print the path of a binary in the active version",
+	Long: `Print the absolute path of pulumi, or of a companion binary such as
+pulumi-language-python, for the version active in the current context: the
+one selected with 'pvm env' if any, then the one pinned in a .pulumi-version
+file in this directory or its parents, otherwise the globally selected
+version.
+
+The main binary of another tool, e.g. 'pvm which esc', is looked up in the
+selected version of that tool.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool := config.Pulumi
+		binary := tool.Binary
+		if len(args) == 1 {
+			binary = args[0]
+			for _, t := range config.Tools {
+				if t.Binary == binary {
+					tool = t
+				}
+			}
+		}
+
+		m := newManager(tool)
+		version, err := activeVersion(m)
+		if err != nil {
+			return fmt.Errorf("failed to get current version: %v", err)
+		}
+		if version == "" {
+			return fmt.Errorf("no %s version currently selected. Use 'pvm use %s' to select one", tool.DisplayName, tool.Spec("<version>"))
+		}
+
+		path, err := m.ExecutablePath(version, binary)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), path)
+		return nil
+	},
+}
+
+var whereCmd = &cobra.Command{
+	Use:               "where <version>",
+	Short:             "Print the install directory of a version",
+	Long:              "Print the absolute install directory of an installed version of Pulumi, or of another tool with e.g. 'pvm where esc@0.9.1'.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeInstalledVersions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool, version, err := config.ParseToolVersion(args[0])
+		if err != nil {
+			return err
+		}
+
+		m := newManager(tool)
+		version, err = m.ResolveInstalled(version)
+		if err != nil {
+			return err
+		}
+
+		path, err := m.VersionDir(version)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), path)
+		return nil
+	},
+}
+
+// activeVersion returns the version of m's tool active in the current
+// context. For the Pulumi CLI that is the one selected with 'pvm env'
+// (recorded in PVM_VERSION) when set, then the installed version matching a
+// .pulumi-version file in the working directory or its parents, otherwise
+// the globally selected version.
+func activeVersion(m versionManager) (string, error) {
+	if m.Tool().Name == config.Pulumi.Name {
+		if version := os.Getenv(config.VersionEnvVar); version != "" {
+			return version, nil
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %v", err)
+		}
+		pinned, pinFile, err := utils.GetPinnedVersion(cwd)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %v", pinFile, err)
+		}
+		if pinned != "" {
+			version, err := m.ResolveInstalled(pinned)
+			if err != nil {
+				return "", fmt.Errorf("version %s pinned in %s is not installed; run 'pvm install'", pinned, pinFile)
+			}
+			return version, nil
+		}
+	}
+	return m.Current()
+}
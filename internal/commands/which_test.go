@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// setupActiveVersion installs a fake version containing the given binaries
+// and points the bin/pulumi symlink at it.
+func setupActiveVersion(t *testing.T, version string, binaries ...string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	versionDir := filepath.Join(tmpDir, "versions", version)
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	for _, name := range binaries {
+		if err := os.WriteFile(filepath.Join(versionDir, name), []byte("#!/bin/sh"), 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(versionDir, "pulumi"), filepath.Join(binDir, "pulumi")); err != nil {
+		t.Fatalf("setup symlink: %v", err)
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	t.Cleanup(config.ResetConfig)
+	return tmpDir
+}
+
+func TestWhichCommand(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.78.1", "pulumi", "pulumi-language-python")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"which", "pulumi-language-python"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := filepath.Join(tmpDir, "versions", "3.78.1", "pulumi-language-python")
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("expected %s, got: %s", want, buf.String())
+	}
+}
+
+func TestWhichCommandUnknownBinary(t *testing.T) {
+	setupActiveVersion(t, "3.78.1", "pulumi")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"which", "pulumi-language-cobol"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for missing binary, got nil")
+	}
+}
+
+func TestWhichCommandNoVersion(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"which"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error when no version is selected, got nil")
+	}
+}
+
+func TestWhichCommandShellVersion(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.78.1", "pulumi")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.90.0"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "versions", "3.90.0", "pulumi"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv(config.VersionEnvVar, "3.90.0")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"which"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := filepath.Join(tmpDir, "versions", "3.90.0", "pulumi")
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("expected the %s version %s, got: %s", config.VersionEnvVar, want, buf.String())
+	}
+}
+
+func TestWhichCommandPinnedVersion(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.78.1", "pulumi")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "versions", "3.90.0"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "versions", "3.90.0", "pulumi"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv(config.VersionEnvVar, "")
+
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, config.PinFile), []byte("3.90\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"which"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join(tmpDir, "versions", "3.90.0", "pulumi")
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("expected the pinned version %s, got: %s", want, buf.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(project, config.PinFile), []byte("3.50.0\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "pinned in") {
+		t.Errorf("expected an error for a pinned version that is not installed, got %v", err)
+	}
+}
+
+func TestWhereCommand(t *testing.T) {
+	tmpDir := setupActiveVersion(t, "3.78.1", "pulumi")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"where", "3.78"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := filepath.Join(tmpDir, "versions", "3.78.1")
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("expected %s, got: %s", want, buf.String())
+	}
+}
+
+func TestWhereCommandNotInstalled(t *testing.T) {
+	config.SetTestConfig(&config.TestConfig{PVMPath: t.TempDir()})
+	defer config.ResetConfig()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"where", "9.9.9"})
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for non-installed version, got nil")
+	}
+}
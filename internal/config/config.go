@@ -1,27 +1,87 @@
 package config
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	PVMDir           = ".pvm"
-	VersionsDir      = "versions"
-	BinDir           = "bin"
-	PulumiBinary     = "pulumi"
-	GithubReleaseURL = "https://github.com/pulumi/pulumi/releases/download/v%s/pulumi-v%s-%s-%s.tar.gz"
-	GithubZipURL     = "https://github.com/pulumi/pulumi/releases/download/v%s/pulumi-v%s-%s-%s.zip"
-	CacheFile        = "releases.cache"
-	CacheTTL         = 24 * time.Hour
+	PVMDir                   = ".pvm"
+	VersionsDir              = "versions"
+	BinDir                   = "bin"
+	ToolsDir                 = "tools"
+	GithubAPIURL             = "https://api.github.com"
+	GithubDownloadURL        = "https://github.com/%s/releases/download/v%s/%s"
+	CacheFile                = "releases.cache"
+	CacheTTL                 = 24 * time.Hour
+	PinFile                  = ".pulumi-version"
+	LockFile                 = "pvm.lock"
+	VersionEnvVar            = "PVM_VERSION"
+	SourceEnvVar             = "PVM_RELEASE_SOURCE"
+	SourceURLEnvVar          = "PVM_RELEASE_URL"
+	CacheTTLEnvVar           = "PVM_CACHE_TTL"
+	PlatformsDir             = "platforms"
+	MirrorDir                = "mirror"
+	PluginsDir               = "plugins"
+	PluginSetsFile           = "plugins.json"
+	UpdateCheckFile          = "update-check.cache"
+	PulumiPluginAsset        = "pulumi-resource-%s-v%s-%s-%s.tar.gz"
+	PulumiPluginURL          = "https://github.com/pulumi/pulumi-%s/releases/download/v%s/" + PulumiPluginAsset
+	PulumiPluginChecksumsURL = "https://github.com/pulumi/pulumi-%s/releases/download/v%s/pulumi-%s_%s_checksums.txt"
 )
 
-// ReleaseCache holds cached GitHub release data.
+// ReleaseCache holds cached release data. Versions is kept alongside
+// Releases so caches stay readable by older pvm versions.
 type ReleaseCache struct {
-	Versions  []string  `json:"versions"`
-	Timestamp time.Time `json:"timestamp"`
+	Versions  []string      `json:"versions"`
+	Releases  []Release     `json:"releases,omitempty"`
+	Pages     []ReleasePage `json:"pages,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	// SourceKind and SourceLocation identify the release source the cache
+	// was filled from, e.g. "mirror" and its URL.
+	SourceKind     string `json:"source_kind,omitempty"`
+	SourceLocation string `json:"source_location,omitempty"`
+}
+
+// ReleasePage records one page of a paginated release listing: the
+// validators its server returned, for revalidating it with a conditional
+// request, and the versions it listed.
+type ReleasePage struct {
+	ETag         string   `json:"etag,omitempty"`
+	LastModified string   `json:"last_modified,omitempty"`
+	Versions     []string `json:"versions"`
+}
+
+// Release describes a published release of a tool.
+type Release struct {
+	Version     string    `json:"version"`
+	PublishedAt time.Time `json:"published_at,omitempty"`
+	Prerelease  bool      `json:"prerelease,omitempty"`
+	Draft       bool      `json:"draft,omitempty"`
+	NotesURL    string    `json:"notes_url,omitempty"`
+	// Assets lists the file names of the release's archives. It is nil when
+	// the release source does not report them.
+	Assets []string `json:"assets,omitempty"`
+}
+
+// HasAsset reports whether the release includes the named asset. Releases
+// whose assets are unknown are assumed to include it.
+func (r Release) HasAsset(name string) bool {
+	if r.Assets == nil {
+		return true
+	}
+	for _, asset := range r.Assets {
+		if asset == name {
+			return true
+		}
+	}
+	return false
 }
 
 // TestConfig holds configuration overrides used during testing.
@@ -53,17 +113,24 @@ func GetHomeDir() string {
 	return home
 }
 
-// GetPVMPath returns the PVM root directory path.
-// The PVM_HOME environment variable overrides the default ~/.pvm location,
-// which is useful for integration testing and custom installations.
+// GetPVMPath returns the PVM root directory path, where versions, the bin
+// symlinks and plugins are kept: ~/.pvm, or $XDG_DATA_HOME/pvm with the xdg
+// layout. The PVM_HOME environment variable overrides it, which is useful
+// for integration testing and custom installations.
 func GetPVMPath() string {
-	if testConfig != nil {
-		return testConfig.PVMPath
-	}
-	if pvmHome := os.Getenv("PVM_HOME"); pvmHome != "" {
-		return pvmHome
-	}
-	return filepath.Join(GetHomeDir(), PVMDir)
+	return CurrentLayout().DataDir
+}
+
+// GetCacheDir returns the directory release caches are kept in: the PVM
+// root, or $XDG_CACHE_HOME/pvm with the xdg layout.
+func GetCacheDir() string {
+	return CurrentLayout().CacheDir
+}
+
+// GetMirrorPath returns the directory 'pvm mirror sync' downloads release
+// archives into and 'pvm mirror serve' serves by default.
+func GetMirrorPath() string {
+	return filepath.Join(GetCacheDir(), MirrorDir)
 }
 
 // GetVersionsPath returns the versions directory path.
@@ -76,7 +143,88 @@ func GetBinPath() string {
 	return filepath.Join(GetPVMPath(), BinDir)
 }
 
+// GetPluginsPath returns the directory pvm installs plugins into.
+func GetPluginsPath() string {
+	return filepath.Join(GetPVMPath(), PluginsDir)
+}
+
+// GetPulumiPluginsPath returns the directory the Pulumi CLI loads plugins
+// from. Like Pulumi itself, it honours the PULUMI_HOME environment variable.
+func GetPulumiPluginsPath() string {
+	if pulumiHome := os.Getenv("PULUMI_HOME"); pulumiHome != "" {
+		return filepath.Join(pulumiHome, PluginsDir)
+	}
+	return filepath.Join(GetHomeDir(), ".pulumi", PluginsDir)
+}
+
 // GetPlatformInfo returns the current OS and architecture.
 func GetPlatformInfo() (string, string) {
 	return runtime.GOOS, runtime.GOARCH
 }
+
+// GetReleaseSource returns the configured kind of release source ("github",
+// "mirror", "index", "dir" or "s3") and its location, from the release_source and
+// release_url settings. It defaults to GitHub.
+func GetReleaseSource() (string, string, error) {
+	kind, err := GetString("release_source")
+	if err != nil {
+		return "", "", err
+	}
+	location, err := GetString("release_url")
+	if err != nil {
+		return "", "", err
+	}
+	return kind, location, nil
+}
+
+// CacheTTLNever is the cache TTL of a release cache that never expires.
+const CacheTTLNever = time.Duration(math.MaxInt64)
+
+// ParseDuration parses a duration such as "90m", "12h" or "7d". Unlike
+// time.ParseDuration it accepts days, the unit people think of caches and
+// release ages in.
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// ParseCacheTTL parses a release cache TTL: a duration, "0" to refresh on
+// every use, or "never" to only refresh when asked to.
+func ParseCacheTTL(s string) (time.Duration, error) {
+	if s == "never" {
+		return CacheTTLNever, nil
+	}
+	ttl, err := ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache TTL %q: use a duration such as 12h or 7d, 0 or never", s)
+	}
+	return ttl, nil
+}
+
+// GetCacheTTL returns how long the release cache is used before it is
+// refreshed, from the cache_ttl setting. It defaults to CacheTTL.
+func GetCacheTTL() (time.Duration, error) {
+	value, err := GetString("cache_ttl")
+	if err != nil {
+		return CacheTTL, err
+	}
+	return ParseCacheTTL(value)
+}
+
+// FormatCacheTTL renders a cache TTL the way ParseCacheTTL accepts it.
+func FormatCacheTTL(ttl time.Duration) string {
+	if ttl == CacheTTLNever {
+		return "never"
+	}
+	return ttl.String()
+}
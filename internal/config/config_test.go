@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestGetHomeDir(t *testing.T) {
@@ -68,3 +69,52 @@ func TestGetPlatformInfo(t *testing.T) {
 		t.Error("GetPlatformInfo() returned empty architecture")
 	}
 }
+
+func TestGetPulumiPluginsPath(t *testing.T) {
+	t.Setenv("PULUMI_HOME", "/custom/pulumi")
+	if got := GetPulumiPluginsPath(); got != filepath.Join("/custom/pulumi", PluginsDir) {
+		t.Errorf("GetPulumiPluginsPath() = %v, want PULUMI_HOME-relative path", got)
+	}
+
+	t.Setenv("PULUMI_HOME", "")
+	expected := filepath.Join(GetHomeDir(), ".pulumi", PluginsDir)
+	if got := GetPulumiPluginsPath(); got != expected {
+		t.Errorf("GetPulumiPluginsPath() = %v, want %v", got, expected)
+	}
+}
+
+func TestParseCacheTTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"0", 0},
+		{"12h", 12 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"never", CacheTTLNever},
+	}
+	for _, tc := range tests {
+		got, err := ParseCacheTTL(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseCacheTTL(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "soon", "-1h", "3"} {
+		if _, err := ParseCacheTTL(in); err == nil {
+			t.Errorf("ParseCacheTTL(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestGetCacheTTL(t *testing.T) {
+	setupConfigLayers(t)
+	if ttl, err := GetCacheTTL(); err != nil || ttl != CacheTTL {
+		t.Errorf("GetCacheTTL() = %v, %v; want the default %v", ttl, err, CacheTTL)
+	}
+	t.Setenv(CacheTTLEnvVar, "never")
+	if ttl, err := GetCacheTTL(); err != nil || ttl != CacheTTLNever {
+		t.Errorf("GetCacheTTL() = %v, %v; want never", ttl, err)
+	}
+}
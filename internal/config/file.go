@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Configuration files are a flat subset of TOML: one "key = value" pair per
+// line, where the value is a basic ("...") or literal ('...') string, a
+// boolean or a number. Tables, arrays and multi-line strings are not
+// supported since pvm has no nested settings.
+
+var configKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ReadConfigFile parses the configuration file at path.
+func ReadConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return values, nil
+}
+
+// parseConfig parses the contents of a configuration file.
+func parseConfig(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		key, value, ok, err := parseConfigLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseConfigLine parses a single line. ok is false for blank lines and
+// comments.
+func parseConfigLine(line string) (key, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	if strings.HasPrefix(line, "[") {
+		return "", "", false, fmt.Errorf("tables are not supported")
+	}
+
+	key, rest, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("expected key = value")
+	}
+	key = strings.TrimSpace(key)
+	if !configKeyPattern.MatchString(key) {
+		return "", "", false, fmt.Errorf("invalid key %q", key)
+	}
+
+	value, err = parseConfigValue(strings.TrimSpace(rest))
+	if err != nil {
+		return "", "", false, fmt.Errorf("%s: %v", key, err)
+	}
+	return key, value, true, nil
+}
+
+// parseConfigValue parses a value followed by an optional comment.
+func parseConfigValue(s string) (string, error) {
+	var value, rest string
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := closingQuote(s)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		unquoted, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", s[:end+1])
+		}
+		value, rest = unquoted, s[end+1:]
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		value, rest = s[1:end+1], s[end+2:]
+	default:
+		value, rest, _ = strings.Cut(s, "#")
+		value = strings.TrimSpace(value)
+		rest = ""
+		if value != "true" && value != "false" {
+			if _, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64); err != nil {
+				return "", fmt.Errorf("invalid value %q (strings must be quoted)", value)
+			}
+		}
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after value", rest)
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the quote ending the basic string s
+// starts with, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// formatConfigValue renders value for a configuration file.
+func formatConfigValue(value string, bare bool) string {
+	if bare {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// writeConfigValue sets key to an already formatted value in the
+// configuration file at path, creating the file if needed. A nil value
+// removes the key. Comments and the order of the
+// other keys are preserved. It reports whether the key was present.
+func writeConfigValue(path, key string, value *string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if _, err := parseConfig(data); err != nil {
+		return false, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	found := false
+	var out []string
+	for _, line := range lines {
+		if lineKey, _, ok, _ := parseConfigLine(line); ok && lineKey == key {
+			found = true
+			if value != nil {
+				out = append(out, key+" = "+*value)
+			}
+			continue
+		}
+		out = append(out, line)
+	}
+	if !found && value != nil {
+		out = append(out, key+" = "+*value)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return found, err
+	}
+	content := strings.Join(out, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return found, os.WriteFile(path, []byte(content), 0644)
+}
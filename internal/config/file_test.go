@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`# pvm settings
+default_version = "3.78.1"   # pinned for CI
+release_url = 'C:\releases'
+escaped = "say \"hi\"\t"
+auto_install = true
+cache_ttl = 0
+
+`)
+	values, err := parseConfig(data)
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	want := map[string]string{
+		"default_version": "3.78.1",
+		"release_url":     `C:\releases`,
+		"escaped":         "say \"hi\"\t",
+		"auto_install":    "true",
+		"cache_ttl":       "0",
+	}
+	if len(values) != len(want) {
+		t.Errorf("expected %d values, got %v", len(want), values)
+	}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("%s = %q, want %q", key, values[key], value)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, data := range []string{
+		"[pvm]\ncolor = true",
+		"default_version = 3.78.1-alpha",
+		"release_source = github",
+		`release_url = "unterminated`,
+		`release_url = "a" "b"`,
+		"color = true\ncolor = false",
+		"just a line",
+	} {
+		if _, err := parseConfig([]byte(data)); err == nil {
+			t.Errorf("parseConfig(%q): expected error, got nil", data)
+		}
+	}
+}
+
+func TestWriteConfigValuePreservesComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	original := "# my settings\ncolor = false # no thanks\ncache_ttl = \"1h\"\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	value := `"7d"`
+	if found, err := writeConfigValue(path, "cache_ttl", &value); err != nil || !found {
+		t.Fatalf("writeConfigValue = %v, %v", found, err)
+	}
+	value = `"dir"`
+	if found, err := writeConfigValue(path, "release_source", &value); err != nil || found {
+		t.Fatalf("writeConfigValue = %v, %v", found, err)
+	}
+	if found, err := writeConfigValue(path, "color", nil); err != nil || !found {
+		t.Fatalf("writeConfigValue = %v, %v", found, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "# my settings\ncache_ttl = \"7d\"\nrelease_source = \"dir\"\n"
+	if string(data) != want {
+		t.Errorf("unexpected file contents:\n%s\nwant:\n%s", data, want)
+	}
+	if values, err := ReadConfigFile(path); err != nil || values["cache_ttl"] != "7d" {
+		t.Errorf("expected the file to parse back, got %v, %v", values, err)
+	}
+	if !strings.HasPrefix(string(data), "# my settings") {
+		t.Error("expected comments to be kept")
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	// LayoutHome keeps everything under ~/.pvm.
+	LayoutHome = "home"
+	// LayoutXDG follows the XDG Base Directory specification.
+	LayoutXDG = "xdg"
+)
+
+// Layout describes where pvm keeps its files.
+type Layout struct {
+	Name string
+	// DataDir holds installed versions, the bin symlinks and plugins.
+	DataDir string
+	// CacheDir holds release caches.
+	CacheDir string
+	// ConfigDir holds the user configuration file.
+	ConfigDir string
+}
+
+// homePVMPath returns the root of the home layout: $PVM_HOME or ~/.pvm.
+func homePVMPath() string {
+	if testConfig != nil {
+		return testConfig.PVMPath
+	}
+	if pvmHome := os.Getenv("PVM_HOME"); pvmHome != "" {
+		return pvmHome
+	}
+	return filepath.Join(GetHomeDir(), PVMDir)
+}
+
+// xdgDir returns the XDG base directory named by env, or its default under
+// the home directory.
+func xdgDir(env string, fallback ...string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	return filepath.Join(append([]string{GetHomeDir()}, fallback...)...)
+}
+
+// GetLayout returns the directories of the named layout.
+func GetLayout(name string) Layout {
+	if name == LayoutXDG {
+		return Layout{
+			Name:      LayoutXDG,
+			DataDir:   filepath.Join(xdgDir("XDG_DATA_HOME", ".local", "share"), "pvm"),
+			CacheDir:  filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), "pvm"),
+			ConfigDir: filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "pvm"),
+		}
+	}
+	root := homePVMPath()
+	return Layout{Name: LayoutHome, DataDir: root, CacheDir: root, ConfigDir: root}
+}
+
+// CurrentLayout returns the layout selected by the layout setting. Test
+// configuration and PVM_HOME pin everything to a single directory.
+func CurrentLayout() Layout {
+	if testConfig != nil || os.Getenv("PVM_HOME") != "" {
+		return GetLayout(LayoutHome)
+	}
+	name, err := GetString("layout")
+	if err != nil {
+		name = LayoutHome
+	}
+	return GetLayout(name)
+}
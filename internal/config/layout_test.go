@@ -0,0 +1,66 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetLayout(t *testing.T) {
+	root := t.TempDir()
+	SetTestConfig(&TestConfig{PVMPath: filepath.Join(root, "home")})
+	defer ResetConfig()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+
+	home := GetLayout(LayoutHome)
+	if home.DataDir != filepath.Join(root, "home") || home.CacheDir != home.DataDir || home.ConfigDir != home.DataDir {
+		t.Errorf("unexpected home layout: %+v", home)
+	}
+
+	xdg := GetLayout(LayoutXDG)
+	want := Layout{
+		Name:      LayoutXDG,
+		DataDir:   filepath.Join(root, "data", "pvm"),
+		CacheDir:  filepath.Join(root, "home", ".cache", "pvm"),
+		ConfigDir: filepath.Join(root, "config", "pvm"),
+	}
+	if xdg != want {
+		t.Errorf("GetLayout(xdg) = %+v, want %+v", xdg, want)
+	}
+}
+
+func TestCurrentLayoutFromSetting(t *testing.T) {
+	root := t.TempDir()
+	ResetConfig()
+	origWorkingDir := workingDir
+	workingDir = func() (string, error) { return root, nil }
+	defer func() { workingDir = origWorkingDir }()
+	for _, setting := range Settings {
+		t.Setenv(setting.EnvVar, "")
+	}
+	t.Setenv("HOME", root)
+	t.Setenv("PVM_HOME", "")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+
+	if got := GetPVMPath(); got != filepath.Join(root, PVMDir) {
+		t.Errorf("expected the home layout by default, got %s", got)
+	}
+
+	if err := SetValue(filepath.Join(root, "config", "pvm", UserConfigFile), "layout", LayoutXDG); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	if got := GetPVMPath(); got != filepath.Join(root, "data", "pvm") {
+		t.Errorf("GetPVMPath() = %s", got)
+	}
+	if got := GetCacheDir(); got != filepath.Join(root, "cache", "pvm") {
+		t.Errorf("GetCacheDir() = %s", got)
+	}
+
+	t.Setenv("PVM_HOME", filepath.Join(root, "pinned"))
+	if got := GetPVMPath(); got != filepath.Join(root, "pinned") {
+		t.Errorf("expected PVM_HOME to override the layout, got %s", got)
+	}
+}
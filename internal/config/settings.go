@@ -0,0 +1,300 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	UserConfigFile    = "config.toml"
+	ProjectConfigFile = ".pvm.toml"
+)
+
+// Setting describes a configuration key.
+type Setting struct {
+	Key         string
+	EnvVar      string
+	Default     string
+	Description string
+	// Bool settings are written unquoted and only accept true or false.
+	Bool bool
+	// UserOnly settings decide where pvm downloads from and where it keeps
+	// its files, so a cloned repository's .pvm.toml must not change them:
+	// they are only read from the environment and the user configuration.
+	UserOnly bool
+	validate func(string) error
+}
+
+// Settings are the keys understood in configuration files.
+var Settings = []Setting{
+	{
+		Key:         "layout",
+		EnvVar:      "PVM_LAYOUT",
+		Default:     LayoutHome,
+		Description: "Where pvm keeps its files: home (~/.pvm) or xdg (XDG base directories); change it with 'pvm migrate'",
+		validate:    oneOf(LayoutHome, LayoutXDG),
+		UserOnly:    true,
+	},
+	{
+		Key:         "default_version",
+		EnvVar:      "PVM_DEFAULT_VERSION",
+		Description: "Pulumi version 'pvm install' and 'pvm use' pick when none is given or pinned",
+	},
+	{
+		Key:         "release_source",
+		EnvVar:      SourceEnvVar,
+		Default:     "github",
+		Description: "Where releases come from: github, mirror, index, dir or s3",
+		validate:    oneOf("github", "mirror", "index", "dir", "s3"),
+		UserOnly:    true,
+	},
+	{
+		Key:         "release_url",
+		EnvVar:      SourceURLEnvVar,
+		Description: "Mirror URL: the 'pvm mirror serve' URL, index, directory or bucket of a non-GitHub release source",
+		UserOnly:    true,
+	},
+	{
+		Key:         "cache_ttl",
+		EnvVar:      CacheTTLEnvVar,
+		Default:     "24h",
+		Description: "How long the release list is cached: a duration such as 12h or 7d, 0 or never",
+		validate:    func(s string) error { _, err := ParseCacheTTL(s); return err },
+	},
+	{
+		Key:         "auto_install",
+		EnvVar:      "PVM_AUTO_INSTALL",
+		Default:     "false",
+		Description: "Install missing versions on 'pvm use' as if --install was given",
+		Bool:        true,
+	},
+	{
+		Key:         "color",
+		EnvVar:      "PVM_COLOR",
+		Default:     "true",
+		Description: "Color output (NO_COLOR and --no-color also disable it)",
+		Bool:        true,
+	},
+	{
+		Key:         "update_check",
+		EnvVar:      "PVM_UPDATE_CHECK",
+		Default:     "false",
+		Description: "Check for a newer pvm release in 'pvm version' (at most once a day)",
+		Bool:        true,
+	},
+	{
+		Key:         "github_token_source",
+		EnvVar:      "PVM_GITHUB_TOKEN_SOURCE",
+		Default:     "env",
+		Description: "Where to get a GitHub API token: env (GITHUB_TOKEN or GH_TOKEN), gh (the GitHub CLI) or none",
+		validate:    oneOf("env", "gh", "none"),
+		UserOnly:    true,
+	},
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(s string) error {
+		for _, a := range allowed {
+			if s == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// LookupSetting returns the setting named key.
+func LookupSetting(key string) (Setting, error) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown setting %q; run 'pvm config list' to see them all", key)
+}
+
+// Validate checks that value is acceptable for the setting.
+func (s Setting) Validate(value string) error {
+	if s.Bool {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid %s %q: must be true or false", s.Key, value)
+		}
+		return nil
+	}
+	if s.validate != nil {
+		if err := s.validate(value); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", s.Key, value, err)
+		}
+	}
+	return nil
+}
+
+// Value is the effective value of a setting and where it came from.
+type Value struct {
+	Setting
+	Value string
+	// Origin is "default", "env:<VAR>" or "file:<path>".
+	Origin string
+}
+
+// workingDir returns the directory project configuration is looked up from.
+// Tests override it.
+var workingDir = os.Getwd
+
+// userConfigCandidates returns the possible user configuration files in
+// order of preference. Finding them must not depend on the layout setting,
+// which they may hold.
+func userConfigCandidates() []string {
+	return []string{
+		filepath.Join(GetLayout(LayoutXDG).ConfigDir, UserConfigFile),
+		filepath.Join(homePVMPath(), UserConfigFile),
+	}
+}
+
+// existingUserConfigPath returns the first existing user configuration file,
+// or an empty string when there is none.
+func existingUserConfigPath() string {
+	for _, path := range userConfigCandidates() {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// UserConfigPath returns the user configuration file: the first existing
+// one of $XDG_CONFIG_HOME/pvm/config.toml and ~/.pvm/config.toml, or the one
+// belonging to the current layout when neither exists.
+func UserConfigPath() string {
+	if path := existingUserConfigPath(); path != "" {
+		return path
+	}
+	return filepath.Join(CurrentLayout().ConfigDir, UserConfigFile)
+}
+
+// ProjectConfigPath returns the closest .pvm.toml in the working directory
+// or its parents, or an empty string when there is none.
+func ProjectConfigPath() string {
+	dir, err := workingDir()
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, ProjectConfigFile)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// configLayer is a configuration file settings are read from.
+type configLayer struct {
+	path    string
+	project bool
+}
+
+// configLayers returns the configuration files in order of precedence.
+func configLayers() []configLayer {
+	var layers []configLayer
+	if project := ProjectConfigPath(); project != "" {
+		layers = append(layers, configLayer{path: project, project: true})
+	}
+	if user := existingUserConfigPath(); user != "" {
+		layers = append(layers, configLayer{path: user})
+	}
+	return layers
+}
+
+// GetValue returns the effective value of key. Sources are consulted in
+// order: the setting's environment variable, the project's .pvm.toml (unless
+// the setting is UserOnly), the user configuration file and finally the
+// default. Command-line flags take precedence over all of them and are
+// handled by the commands.
+func GetValue(key string) (Value, error) {
+	setting, err := LookupSetting(key)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if value := os.Getenv(setting.EnvVar); value != "" {
+		if err := setting.Validate(value); err != nil {
+			return Value{}, fmt.Errorf("%v (from %s)", err, setting.EnvVar)
+		}
+		return Value{Setting: setting, Value: value, Origin: "env:" + setting.EnvVar}, nil
+	}
+
+	for _, layer := range configLayers() {
+		if layer.project && setting.UserOnly {
+			continue
+		}
+		path := layer.path
+		values, err := ReadConfigFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return Value{}, err
+		}
+		if value, ok := values[key]; ok {
+			if err := setting.Validate(value); err != nil {
+				return Value{}, fmt.Errorf("%v (from %s)", err, path)
+			}
+			return Value{Setting: setting, Value: value, Origin: "file:" + path}, nil
+		}
+	}
+
+	return Value{Setting: setting, Value: setting.Default, Origin: "default"}, nil
+}
+
+// GetString returns the effective value of key.
+func GetString(key string) (string, error) {
+	value, err := GetValue(key)
+	return value.Value, err
+}
+
+// GetBool returns the effective value of a boolean setting.
+func GetBool(key string) (bool, error) {
+	value, err := GetValue(key)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(value.Value)
+}
+
+// SetValue validates value and records it for key in the configuration
+// file at path.
+func SetValue(path, key, value string) error {
+	setting, err := LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	if setting.UserOnly && filepath.Base(path) == ProjectConfigFile {
+		return fmt.Errorf("%s can only be set in the user configuration, not in %s", key, ProjectConfigFile)
+	}
+	if err := setting.Validate(value); err != nil {
+		return err
+	}
+	formatted := formatConfigValue(value, setting.Bool)
+	_, err = writeConfigValue(path, key, &formatted)
+	return err
+}
+
+// UnsetValue removes key from the configuration file at path. It reports
+// whether the key was set there.
+func UnsetValue(path, key string) (bool, error) {
+	if _, err := LookupSetting(key); err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+	return writeConfigValue(path, key, nil)
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupConfigLayers points the user configuration at a temp PVM home and
+// the working directory at a temp project, and clears the environment.
+func setupConfigLayers(t *testing.T) (home, project string) {
+	t.Helper()
+	home = t.TempDir()
+	project = t.TempDir()
+	SetTestConfig(&TestConfig{PVMPath: home})
+	t.Cleanup(ResetConfig)
+
+	origWorkingDir := workingDir
+	workingDir = func() (string, error) { return filepath.Join(project, "sub"), nil }
+	t.Cleanup(func() { workingDir = origWorkingDir })
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	for _, setting := range Settings {
+		t.Setenv(setting.EnvVar, "")
+	}
+	return home, project
+}
+
+func TestGetValueLayers(t *testing.T) {
+	home, project := setupConfigLayers(t)
+
+	value, err := GetValue("cache_ttl")
+	if err != nil || value.Value != "24h" || value.Origin != "default" {
+		t.Fatalf("expected the default, got %+v, %v", value, err)
+	}
+
+	userFile := filepath.Join(home, UserConfigFile)
+	if err := SetValue(userFile, "cache_ttl", "12h"); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	value, _ = GetValue("cache_ttl")
+	if value.Value != "12h" || value.Origin != "file:"+userFile {
+		t.Errorf("expected the user file to win over the default, got %+v", value)
+	}
+
+	projectFile := filepath.Join(project, ProjectConfigFile)
+	if err := SetValue(projectFile, "cache_ttl", "1h"); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	value, _ = GetValue("cache_ttl")
+	if value.Value != "1h" || value.Origin != "file:"+projectFile {
+		t.Errorf("expected the project file to win over the user file, got %+v", value)
+	}
+
+	t.Setenv(CacheTTLEnvVar, "never")
+	value, _ = GetValue("cache_ttl")
+	if value.Value != "never" || value.Origin != "env:"+CacheTTLEnvVar {
+		t.Errorf("expected the environment to win over files, got %+v", value)
+	}
+}
+
+func TestUserConfigPathXDG(t *testing.T) {
+	home, _ := setupConfigLayers(t)
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	if got := UserConfigPath(); got != filepath.Join(home, UserConfigFile) {
+		t.Errorf("expected the PVM home config without an XDG one, got %s", got)
+	}
+
+	xdgFile := filepath.Join(xdg, "pvm", UserConfigFile)
+	if err := os.MkdirAll(filepath.Dir(xdgFile), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(xdgFile, []byte("color = false\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if got := UserConfigPath(); got != xdgFile {
+		t.Errorf("expected %s, got %s", xdgFile, got)
+	}
+	if color, err := GetBool("color"); err != nil || color {
+		t.Errorf("expected color to be disabled by the XDG config, got %v, %v", color, err)
+	}
+}
+
+func TestSettingValidation(t *testing.T) {
+	home, _ := setupConfigLayers(t)
+	userFile := filepath.Join(home, UserConfigFile)
+
+	if err := SetValue(userFile, "release_source", "ftp"); err == nil {
+		t.Error("expected an invalid release source to be rejected")
+	}
+	if err := SetValue(userFile, "auto_install", "yes please"); err == nil {
+		t.Error("expected an invalid boolean to be rejected")
+	}
+	if err := SetValue(userFile, "no_such_key", "1"); err == nil {
+		t.Error("expected an unknown key to be rejected")
+	}
+
+	// Hand-edited files are validated when read.
+	if err := os.WriteFile(userFile, []byte("cache_ttl = \"soon\"\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := GetCacheTTL(); err == nil || !strings.Contains(err.Error(), userFile) {
+		t.Errorf("expected an error naming %s, got %v", userFile, err)
+	}
+}
+
+func TestUnsetValue(t *testing.T) {
+	home, _ := setupConfigLayers(t)
+	userFile := filepath.Join(home, UserConfigFile)
+
+	if found, err := UnsetValue(userFile, "color"); err != nil || found {
+		t.Errorf("UnsetValue without a file = %v, %v", found, err)
+	}
+	if err := SetValue(userFile, "color", "false"); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	if data, _ := os.ReadFile(userFile); string(data) != "color = false\n" {
+		t.Errorf("expected booleans to be written unquoted, got %q", data)
+	}
+	if found, err := UnsetValue(userFile, "color"); err != nil || !found {
+		t.Errorf("UnsetValue = %v, %v", found, err)
+	}
+	if color, _ := GetBool("color"); !color {
+		t.Error("expected color to fall back to its default")
+	}
+}
+
+func TestProjectConfigCannotChangeReleaseSource(t *testing.T) {
+	_, project := setupConfigLayers(t)
+	projectFile := filepath.Join(project, ProjectConfigFile)
+
+	if err := SetValue(projectFile, "release_source", "mirror"); err == nil {
+		t.Error("expected release_source to be rejected in a project file")
+	}
+
+	// A hand-written .pvm.toml, e.g. in a cloned repository, is ignored for
+	// user-only settings but still read for the others.
+	data := "release_source = \"mirror\"\nrelease_url = \"https://mirror.example.com\"\nauto_install = true\n"
+	if err := os.WriteFile(projectFile, []byte(data), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if value, err := GetValue("release_source"); err != nil || value.Value != "github" || value.Origin != "default" {
+		t.Errorf("expected the project file not to change the release source, got %+v, %v", value, err)
+	}
+	if value, _ := GetValue("release_url"); value.Value != "" {
+		t.Errorf("expected the project file not to set release_url, got %+v", value)
+	}
+	if value, _ := GetValue("auto_install"); value.Value != "true" || value.Origin != "file:"+projectFile {
+		t.Errorf("expected other settings to be read from the project file, got %+v", value)
+	}
+}
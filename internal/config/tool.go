@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Tool describes a CLI published through GitHub releases that pvm can install
+// and switch between, such as the Pulumi CLI itself or Pulumi ESC.
+type Tool struct {
+	// Name identifies the tool on the command line, e.g. "esc" in "esc@0.9.1".
+	Name string
+	// DisplayName is used in messages, e.g. "Switched to Pulumi ESC 0.9.1".
+	DisplayName string
+	// Binary is the main executable. Its symlink in the bin directory records
+	// which version of the tool is active.
+	Binary string
+	// Companions are the other executables release archives ship next to
+	// Binary, without the .exe or .cmd extension of Windows builds.
+	Companions []string
+	// Repo is the GitHub repository the tool's releases are published in.
+	Repo string
+	// Asset is the release asset name template. {version}, {os}, {arch} and
+	// {ext} are substituted when building download URLs.
+	Asset string
+	// Checksums is the name template of the release's SHA-256 checksums file,
+	// or empty when the tool does not publish one. {version} is substituted.
+	Checksums string
+	// ArchNames maps Go architecture names to the names used in asset names.
+	ArchNames map[string]string
+	// CacheFile is the release list cache, relative to the PVM directory.
+	CacheFile string
+}
+
+var (
+	// Pulumi is the Pulumi CLI, the tool pvm manages by default.
+	Pulumi = Tool{
+		Name:        "pulumi",
+		DisplayName: "Pulumi",
+		Binary:      "pulumi",
+		Repo:        "pulumi/pulumi",
+		Asset:       "pulumi-v{version}-{os}-{arch}.{ext}",
+		Checksums:   "pulumi-{version}-checksums.txt",
+		ArchNames:   map[string]string{"amd64": "x64"},
+		CacheFile:   CacheFile,
+		Companions: []string{
+			"pulumi-analyzer-policy",
+			"pulumi-analyzer-policy-python",
+			"pulumi-language-dotnet",
+			"pulumi-language-go",
+			"pulumi-language-java",
+			"pulumi-language-nodejs",
+			"pulumi-language-python",
+			"pulumi-language-python-exec",
+			"pulumi-language-yaml",
+			"pulumi-resource-pulumi-nodejs",
+			"pulumi-resource-pulumi-python",
+			"pulumi-watch",
+		},
+	}
+
+	// ESC is the Pulumi ESC (Environments, Secrets and Configuration) CLI.
+	ESC = Tool{
+		Name:        "esc",
+		DisplayName: "Pulumi ESC",
+		Binary:      "esc",
+		Repo:        "pulumi/esc",
+		Asset:       "esc-v{version}-{os}-{arch}.{ext}",
+		ArchNames:   map[string]string{"amd64": "x64"},
+		CacheFile:   "esc-releases.cache",
+	}
+
+	// PVM is pvm itself, as published for 'pvm self-update'. Its archives
+	// hold the binary at their root. It is not in Tools: pvm does not
+	// install versions of itself.
+	PVM = Tool{
+		Name:        "pvm",
+		DisplayName: "pvm",
+		Binary:      "pvm",
+		Repo:        "tomski747/pvm",
+		Asset:       "v{version}-{os}-{arch}.{ext}",
+		Checksums:   "v{version}-checksums.txt",
+		CacheFile:   "pvm-releases.cache",
+	}
+
+	// Tools is the registry of tools pvm can manage.
+	Tools = []Tool{Pulumi, ESC}
+)
+
+// LookupTool returns the registered tool with the given name.
+func LookupTool(name string) (Tool, error) {
+	for _, tool := range Tools {
+		if tool.Name == name {
+			return tool, nil
+		}
+	}
+	names := make([]string, len(Tools))
+	for i, tool := range Tools {
+		names[i] = tool.Name
+	}
+	return Tool{}, fmt.Errorf("unknown tool %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// ParseToolVersion splits a "tool@version" argument into the tool and the
+// version. Arguments without a tool prefix refer to the Pulumi CLI.
+func ParseToolVersion(spec string) (Tool, string, error) {
+	name, version, found := strings.Cut(spec, "@")
+	if !found {
+		return Pulumi, spec, nil
+	}
+	tool, err := LookupTool(name)
+	if err != nil {
+		return Tool{}, "", err
+	}
+	if version == "" {
+		return Tool{}, "", fmt.Errorf("missing version in %q", spec)
+	}
+	return tool, version, nil
+}
+
+// Spec formats a version of the tool the way users type it on the command
+// line: a bare version for Pulumi and "tool@version" for everything else.
+func (t Tool) Spec(version string) string {
+	if t.Name == Pulumi.Name {
+		return version
+	}
+	return t.Name + "@" + version
+}
+
+// AssetName returns the name of the release asset for a version and platform.
+// Windows releases are zip archives; all others are gzipped tarballs.
+func (t Tool) AssetName(version, goos, arch string) string {
+	if name, ok := t.ArchNames[arch]; ok {
+		arch = name
+	}
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return strings.NewReplacer(
+		"{version}", version,
+		"{os}", goos,
+		"{arch}", arch,
+		"{ext}", ext,
+	).Replace(t.Asset)
+}
+
+// ChecksumsName returns the name of the checksums file published with a
+// version, or an empty string when the tool does not publish one.
+func (t Tool) ChecksumsName(version string) string {
+	return strings.ReplaceAll(t.Checksums, "{version}", version)
+}
+
+// DownloadURL returns the GitHub release download URL of the release asset
+// for a version and platform.
+func (t Tool) DownloadURL(version, goos, arch string) string {
+	return fmt.Sprintf(GithubDownloadURL, t.Repo, version, t.AssetName(version, goos, arch))
+}
+
+// VersionsPath returns the directory versions of the tool are installed in
+// under the PVM directory root. The Pulumi CLI keeps the original
+// <root>/versions location; other tools live under <root>/tools/<name>/versions.
+func (t Tool) VersionsPath(root string) string {
+	if t.Name == Pulumi.Name {
+		return filepath.Join(root, VersionsDir)
+	}
+	return filepath.Join(root, ToolsDir, t.Name, VersionsDir)
+}
+
+// CachePath returns the path of the tool's release list cache in the cache
+// directory dir.
+func (t Tool) CachePath(dir string) string {
+	return filepath.Join(dir, t.CacheFile)
+}
+
+// GetToolVersionsPath returns the directory versions of tool are installed in.
+func GetToolVersionsPath(tool Tool) string {
+	return tool.VersionsPath(GetPVMPath())
+}
+
+// GetToolCachePath returns the path of tool's release list cache.
+func GetToolCachePath(tool Tool) string {
+	return tool.CachePath(GetCacheDir())
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseToolVersion(t *testing.T) {
+	tests := []struct {
+		spec        string
+		wantTool    string
+		wantVersion string
+		wantErr     bool
+	}{
+		{"3.78.1", "pulumi", "3.78.1", false},
+		{"latest", "pulumi", "latest", false},
+		{"esc@0.9.1", "esc", "0.9.1", false},
+		{"pulumi@3.78.1", "pulumi", "3.78.1", false},
+		{"esc@", "", "", true},
+		{"nope@1.0.0", "", "", true},
+	}
+
+	for _, tt := range tests {
+		tool, version, err := ParseToolVersion(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseToolVersion(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if tool.Name != tt.wantTool || version != tt.wantVersion {
+			t.Errorf("ParseToolVersion(%q) = %s, %s; want %s, %s", tt.spec, tool.Name, version, tt.wantTool, tt.wantVersion)
+		}
+	}
+}
+
+func TestToolSpec(t *testing.T) {
+	if got := Pulumi.Spec("3.78.1"); got != "3.78.1" {
+		t.Errorf("Pulumi.Spec() = %q, want %q", got, "3.78.1")
+	}
+	if got := ESC.Spec("0.9.1"); got != "esc@0.9.1" {
+		t.Errorf("ESC.Spec() = %q, want %q", got, "esc@0.9.1")
+	}
+}
+
+func TestToolDownloadURL(t *testing.T) {
+	tests := []struct {
+		tool Tool
+		goos string
+		arch string
+		want string
+	}{
+		{Pulumi, "linux", "amd64", "https://github.com/pulumi/pulumi/releases/download/v3.78.1/pulumi-v3.78.1-linux-x64.tar.gz"},
+		{Pulumi, "windows", "arm64", "https://github.com/pulumi/pulumi/releases/download/v3.78.1/pulumi-v3.78.1-windows-arm64.zip"},
+		{ESC, "darwin", "amd64", "https://github.com/pulumi/esc/releases/download/v3.78.1/esc-v3.78.1-darwin-x64.tar.gz"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.tool.DownloadURL("3.78.1", tt.goos, tt.arch); got != tt.want {
+			t.Errorf("%s.DownloadURL(%s, %s) = %s, want %s", tt.tool.Name, tt.goos, tt.arch, got, tt.want)
+		}
+	}
+}
+
+func TestGetToolVersionsPath(t *testing.T) {
+	testDir := "/test/pvm"
+	SetTestConfig(&TestConfig{PVMPath: testDir})
+	defer ResetConfig()
+
+	if got := GetToolVersionsPath(Pulumi); got != GetVersionsPath() {
+		t.Errorf("GetToolVersionsPath(Pulumi) = %v, want %v", got, GetVersionsPath())
+	}
+	want := filepath.Join(testDir, ToolsDir, "esc", VersionsDir)
+	if got := GetToolVersionsPath(ESC); got != want {
+		t.Errorf("GetToolVersionsPath(ESC) = %v, want %v", got, want)
+	}
+}
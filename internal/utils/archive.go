@@ -4,6 +4,9 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,21 +15,235 @@ import (
 	"strings"
 )
 
-func downloadAndExtract(url string, destDir string, isZip bool) error {
-	resp, err := http.Get(url)
+// DownloadAndExtract downloads the archive at url and unpacks it into destDir,
+// dropping the first strip path components of every entry (Pulumi CLI
+// archives wrap their contents in a top-level directory; plugin archives do not).
+// When checksum is set, the archive's SHA-256 must match it before anything
+// is extracted. file:// URLs are read from the local filesystem.
+// Canceling ctx aborts both the download and the extraction and returns
+// ctx's error; the caller is responsible for removing the partial destDir.
+func DownloadAndExtract(ctx context.Context, client *http.Client, url string, destDir string, isZip bool, strip int, checksum string) error {
+	body, err := openArchive(ctx, client, url)
 	if err != nil {
-		return fmt.Errorf("failed to download: %v", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
 	}
-	defer resp.Body.Close()
+	defer body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	var r io.Reader = contextReader{ctx, body}
+	if checksum != "" {
+		verified, err := verifyChecksum(r, checksum)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		defer os.Remove(verified.Name())
+		defer verified.Close()
+		r = contextReader{ctx, verified}
 	}
 
 	if isZip {
-		return extractZip(resp.Body, destDir)
+		err = extractZip(ctx, r, destDir, strip)
+	} else {
+		err = extractTarGz(ctx, r, destDir, strip)
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// ArchiveChecksum downloads the archive at url and returns its hex-encoded
+// SHA-256.
+func ArchiveChecksum(ctx context.Context, client *http.Client, url string) (string, error) {
+	body, err := openArchive(ctx, client, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, contextReader{ctx, body}); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("failed to download: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DownloadFile downloads url to path and returns the file's hex-encoded
+// SHA-256. When checksum is set it must match, or path is removed and an
+// error returned.
+func DownloadFile(ctx context.Context, client *http.Client, url, path, checksum string) (string, error) {
+	body, err := openArchive(ctx, client, url)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if err := writeFile(path, io.TeeReader(contextReader{ctx, body}, hash)); err != nil {
+		os.Remove(path)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	got := hex.EncodeToString(hash.Sum(nil))
+	if checksum != "" && !strings.EqualFold(got, checksum) {
+		os.Remove(path)
+		return "", fmt.Errorf("checksum mismatch: expected %s, got %s", checksum, got)
+	}
+	return got, nil
+}
+
+// ParseChecksums finds the checksum of asset in a checksums file made of
+// "<sha256>  <file name>" lines, as published alongside Pulumi releases.
+func ParseChecksums(data []byte, asset string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// CreateTar writes the regular files directly inside dir to w as an
+// uncompressed tar archive, in name order.
+func CreateTar(w io.Writer, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := addTarFile(tw, filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// addTarFile adds the file at path to tw under its base name.
+func addTarFile(tw *tar.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: info.Name(), Mode: 0644, Size: info.Size(), ModTime: info.ModTime(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %v", info.Name(), err)
 	}
-	return extractTarGz(resp.Body, destDir)
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %v", info.Name(), err)
+	}
+	return nil
+}
+
+// ExtractTar unpacks the uncompressed tar archive at path into destDir.
+func ExtractTar(ctx context.Context, path, destDir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := extractTar(ctx, contextReader{ctx, f}, destDir, 0); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// openArchive starts reading the archive at url.
+func openArchive(ctx context.Context, client *http.Client, url string) (io.ReadCloser, error) {
+	if path, ok := strings.CutPrefix(url, "file://"); ok {
+		f, err := os.Open(filepath.FromSlash(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %v", err)
+		}
+		return f, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// verifyChecksum buffers r to a temp file and checks its SHA-256 against
+// checksum. It returns the temp file rewound to the start; the caller closes
+// and removes it.
+func verifyChecksum(r io.Reader, checksum string) (*os.File, error) {
+	tmpFile, err := os.CreateTemp("", "pvm-download-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %v", err)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmpFile, hash), r); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return nil, fmt.Errorf("failed to download: %v", err)
+	}
+
+	if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, checksum) {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return nil, fmt.Errorf("checksum mismatch: expected %s, got %s", checksum, got)
+	}
+
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return nil, err
+	}
+	return tmpFile, nil
+}
+
+// contextReader fails reads once ctx is done, so copying a large archive
+// entry stops promptly on cancellation.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
 }
 
 // safeJoin joins destDir and relPath and verifies the result stays inside destDir.
@@ -40,16 +257,23 @@ func safeJoin(destDir, relPath string) (string, error) {
 	return path, nil
 }
 
-func extractTarGz(r io.Reader, destDir string) error {
+func extractTarGz(ctx context.Context, r io.Reader, destDir string, strip int) error {
 	gzr, err := gzip.NewReader(r)
 	if err != nil {
 		return fmt.Errorf("failed to create gzip reader: %v", err)
 	}
 	defer gzr.Close()
+	return extractTar(ctx, gzr, destDir, strip)
+}
 
-	tr := tar.NewReader(gzr)
+func extractTar(ctx context.Context, r io.Reader, destDir string, strip int) error {
+	tr := tar.NewReader(r)
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		header, err := tr.Next()
 		if err == io.EOF {
 			break
@@ -58,12 +282,12 @@ func extractTarGz(r io.Reader, destDir string) error {
 			return fmt.Errorf("failed to read tar: %v", err)
 		}
 
-		// Skip the top-level directory entry
+		// Skip the stripped leading directory entries
 		parts := strings.Split(header.Name, string(filepath.Separator))
-		if len(parts) <= 1 {
+		if len(parts) <= strip {
 			continue
 		}
-		relPath := filepath.Join(parts[1:]...)
+		relPath := filepath.Join(parts[strip:]...)
 
 		path, err := safeJoin(destDir, relPath)
 		if err != nil {
@@ -79,7 +303,7 @@ func extractTarGz(r io.Reader, destDir string) error {
 			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 				return fmt.Errorf("failed to create directory: %v", err)
 			}
-			if err := writeFile(path, tr); err != nil {
+			if err := writeFile(path, contextReader{ctx, tr}); err != nil {
 				return err
 			}
 		}
@@ -106,7 +330,7 @@ func writeFile(path string, r io.Reader) error {
 	return nil
 }
 
-func extractZip(r io.Reader, destDir string) error {
+func extractZip(ctx context.Context, r io.Reader, destDir string, strip int) error {
 	// zip.Reader requires io.ReaderAt, so buffer to a temp file first.
 	tmpFile, err := os.CreateTemp("", "pulumi-*.zip")
 	if err != nil {
@@ -115,7 +339,7 @@ func extractZip(r io.Reader, destDir string) error {
 	defer os.Remove(tmpFile.Name())
 	defer tmpFile.Close()
 
-	if _, err := io.Copy(tmpFile, r); err != nil {
+	if _, err := io.Copy(tmpFile, contextReader{ctx, r}); err != nil {
 		return fmt.Errorf("failed to write temp file: %v", err)
 	}
 
@@ -126,12 +350,16 @@ func extractZip(r io.Reader, destDir string) error {
 	defer zipReader.Close()
 
 	for _, file := range zipReader.File {
-		// Skip the top-level directory entry
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skip the stripped leading directory entries
 		parts := strings.Split(file.Name, string(filepath.Separator))
-		if len(parts) <= 1 {
+		if len(parts) <= strip {
 			continue
 		}
-		relPath := filepath.Join(parts[1:]...)
+		relPath := filepath.Join(parts[strip:]...)
 
 		path, err := safeJoin(destDir, relPath)
 		if err != nil {
@@ -153,7 +381,7 @@ func extractZip(r io.Reader, destDir string) error {
 		if err != nil {
 			return fmt.Errorf("failed to open zip entry: %v", err)
 		}
-		if err := writeFile(path, rc); err != nil {
+		if err := writeFile(path, contextReader{ctx, rc}); err != nil {
 			_ = rc.Close()
 			return err
 		}
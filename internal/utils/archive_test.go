@@ -4,8 +4,13 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -48,7 +53,7 @@ func TestExtractTarGz(t *testing.T) {
 		"pulumi-language": "#!/bin/sh\necho lang",
 	})
 
-	if err := extractTarGz(archive, destDir); err != nil {
+	if err := extractTarGz(context.Background(), archive, destDir, 1); err != nil {
 		t.Fatalf("extractTarGz: %v", err)
 	}
 
@@ -78,7 +83,7 @@ func TestExtractTarGzPathTraversal(t *testing.T) {
 	_ = tw.Close()
 	_ = gzw.Close()
 
-	err := extractTarGz(&buf, destDir)
+	err := extractTarGz(context.Background(), &buf, destDir, 1)
 	if err == nil {
 		t.Fatal("expected error for path traversal, got nil")
 	}
@@ -103,3 +108,122 @@ func TestSafeJoin(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractTarGzNoStrip(t *testing.T) {
+	destDir := t.TempDir()
+
+	archive := bytes.NewReader(buildFlatTarGz(t, map[string]string{
+		"pulumi-resource-aws": "#!/bin/sh\necho aws",
+	}))
+
+	if err := extractTarGz(context.Background(), archive, destDir, 0); err != nil {
+		t.Fatalf("extractTarGz: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "pulumi-resource-aws")); err != nil {
+		t.Errorf("expected top-level file to be extracted: %v", err)
+	}
+}
+
+func TestExtractTarGzCanceled(t *testing.T) {
+	archive := buildTarGz(t, map[string]string{"pulumi": "#!/bin/sh"})
+	destDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := extractTarGz(ctx, archive, destDir, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "pulumi")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be extracted after cancellation")
+	}
+}
+
+func TestDownloadAndExtractVerifiesChecksum(t *testing.T) {
+	archive := buildTarGz(t, map[string]string{"pulumi": "#!/bin/sh"}).Bytes()
+	archivePath := filepath.Join(t.TempDir(), "pulumi.tar.gz")
+	if err := os.WriteFile(archivePath, archive, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	url := "file://" + filepath.ToSlash(archivePath)
+	sum := sha256.Sum256(archive)
+
+	destDir := t.TempDir()
+	if err := DownloadAndExtract(context.Background(), nil, url, destDir, false, 1, hex.EncodeToString(sum[:])); err != nil {
+		t.Fatalf("DownloadAndExtract: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "pulumi")); err != nil {
+		t.Errorf("expected pulumi to be extracted: %v", err)
+	}
+
+	destDir = t.TempDir()
+	err := DownloadAndExtract(context.Background(), nil, url, destDir, false, 1, strings.Repeat("0", 64))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "pulumi")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be extracted when the checksum does not match")
+	}
+}
+
+func TestCreateAndExtractTar(t *testing.T) {
+	srcDir := t.TempDir()
+	for name, content := range map[string]string{"a.tar.gz": "archive", "SHA256SUMS": "sums"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	tarPath := filepath.Join(t.TempDir(), "bundle.tar")
+	f, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := CreateTar(f, srcDir); err != nil {
+		t.Fatalf("CreateTar: %v", err)
+	}
+	f.Close()
+
+	destDir := t.TempDir()
+	if err := ExtractTar(context.Background(), tarPath, destDir); err != nil {
+		t.Fatalf("ExtractTar: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(destDir, "SHA256SUMS"))
+	if err != nil || string(data) != "sums" {
+		t.Errorf("expected SHA256SUMS to round-trip, got %q, %v", data, err)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(srcPath, []byte("archive"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	url := "file://" + filepath.ToSlash(srcPath)
+	sum := sha256.Sum256([]byte("archive"))
+
+	destPath := filepath.Join(t.TempDir(), "copy.tar.gz")
+	got, err := DownloadFile(context.Background(), nil, url, destPath, "")
+	if err != nil || got != hex.EncodeToString(sum[:]) {
+		t.Fatalf("DownloadFile = %s, %v; want %x", got, err, sum)
+	}
+
+	_, err = DownloadFile(context.Background(), nil, url, destPath, strings.Repeat("0", 64))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		t.Error("expected the download to be removed when the checksum does not match")
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	data := []byte("1111  pulumi-v3.78.1-darwin-x64.tar.gz\n2222 *pulumi-v3.78.1-windows-x64.zip\n")
+	if got := ParseChecksums(data, "pulumi-v3.78.1-windows-x64.zip"); got != "2222" {
+		t.Errorf("expected 2222, got %q", got)
+	}
+	if got := ParseChecksums(data, "pulumi-v3.78.1-linux-x64.tar.gz"); got != "" {
+		t.Errorf("expected no checksum, got %q", got)
+	}
+}
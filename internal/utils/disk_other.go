@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || dragonfly || windows)
+
+package utils
+
+import "errors"
+
+// freeDiskSpace is not implemented on this platform; 'pvm doctor' reports
+// the free space as unknown.
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
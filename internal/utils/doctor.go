@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// CheckStatus is the outcome of a single diagnostic check.
+type CheckStatus int
+
+const (
+	CheckPass CheckStatus = iota
+	CheckWarn
+	CheckFail
+)
+
+// String returns the short label printed next to each check.
+func (s CheckStatus) String() string {
+	switch s {
+	case CheckPass:
+		return "pass"
+	case CheckWarn:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+// CheckResult describes the outcome of a diagnostic check and, when it did
+// not pass, a hint on how to fix the problem.
+type CheckResult struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+	Hint    string
+}
+
+// minFreeDiskSpace is the free space below which the disk check warns.
+// A single Pulumi release unpacks to roughly 300MB.
+const minFreeDiskSpace = 500 * 1024 * 1024
+
+// doctorHTTPTimeout bounds the reachability check so doctor never hangs.
+var doctorHTTPTimeout = 5 * time.Second
+
+// SourceProbe checks that the configured release source answers, e.g. by
+// asking it for the latest release.
+type SourceProbe func(ctx context.Context) error
+
+// RunDiagnostics runs every environment check and returns the results in
+// the order they should be reported. probe is used to reach the release
+// source.
+func RunDiagnostics(ctx context.Context, probe SourceProbe) []CheckResult {
+	return []CheckResult{
+		checkBinOnPath(),
+		checkSymlinks(),
+		checkReleaseCache(),
+		checkReleaseSource(ctx, probe),
+		checkDiskSpace(),
+		checkPermissions(),
+	}
+}
+
+// executableName returns the file name of binary as the shell would look
+// it up on PATH.
+func executableName(binary string) string {
+	if runtime.GOOS == "windows" {
+		return binary + ".exe"
+	}
+	return binary
+}
+
+// FindOnPath returns the first file named name in the directories listed in
+// PATH, or an empty string when there is none.
+func FindOnPath(name string) string {
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		candidate := filepath.Join(dir, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// samePath reports whether two paths refer to the same file or directory.
+func samePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ai, errA := os.Stat(a)
+	bi, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(ai, bi)
+}
+
+func checkBinOnPath() CheckResult {
+	return CheckBinOnPath(config.Pulumi.Binary)
+}
+
+// CheckBinOnPath checks that the pvm bin directory is on PATH and that the
+// first binary named binary found there is pvm's, rather than another
+// installation (e.g. Homebrew's or ~/.pulumi/bin) shadowing it. Links to
+// pvm's binary, such as those 'pvm import --replace' leaves, do not count
+// as shadowing.
+func CheckBinOnPath(binary string) CheckResult {
+	result := CheckResult{Name: "PATH"}
+	binPath := config.GetBinPath()
+
+	onPath := false
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" && samePath(dir, binPath) {
+			onPath = true
+			break
+		}
+	}
+	if !onPath {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("%s is not on PATH", binPath)
+		result.Hint = fmt.Sprintf("add 'export PATH=\"%s:$PATH\"' to your shell profile", binPath)
+		return result
+	}
+
+	name := executableName(binary)
+	first := FindOnPath(name)
+	if first != "" && !samePath(filepath.Dir(first), binPath) && !samePath(first, filepath.Join(binPath, name)) {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%s shadows the pvm-managed %s", first, binary)
+		result.Hint = fmt.Sprintf("move %s ahead of %s in PATH or remove the other installation", binPath, filepath.Dir(first))
+		return result
+	}
+
+	result.Status = CheckPass
+	result.Message = fmt.Sprintf("%s is on PATH", binPath)
+	return result
+}
+
+func checkSymlinks() CheckResult {
+	result := CheckResult{Name: "symlinks"}
+	binPath := config.GetBinPath()
+
+	files, err := os.ReadDir(binPath)
+	if os.IsNotExist(err) {
+		result.Status = CheckWarn
+		result.Message = "no version has been selected yet"
+		result.Hint = "run 'pvm use <version>' to select one"
+		return result
+	}
+	if err != nil {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("failed to read %s: %v", binPath, err)
+		result.Hint = fmt.Sprintf("check the permissions of %s", binPath)
+		return result
+	}
+
+	var dangling []string
+	for _, file := range files {
+		filePath := filepath.Join(binPath, file.Name())
+		info, err := os.Lstat(filePath)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if _, err := os.Stat(filePath); err != nil {
+			dangling = append(dangling, file.Name())
+		}
+	}
+
+	if len(dangling) > 0 {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("%d dangling symlink(s) in %s: %v", len(dangling), binPath, dangling)
+		result.Hint = "run 'pvm use <version>' to recreate them"
+		return result
+	}
+
+	result.Status = CheckPass
+	result.Message = fmt.Sprintf("all symlinks in %s resolve", binPath)
+	return result
+}
+
+func checkReleaseCache() CheckResult {
+	result := CheckResult{Name: "release cache"}
+	cachePath := config.GetToolCachePath(config.Pulumi)
+
+	data, err := os.ReadFile(cachePath)
+	if os.IsNotExist(err) {
+		result.Status = CheckWarn
+		result.Message = "release cache has not been created yet"
+		result.Hint = "run 'pvm list --all' to populate it"
+		return result
+	}
+	if err != nil {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("failed to read %s: %v", cachePath, err)
+		result.Hint = fmt.Sprintf("check the permissions of %s", cachePath)
+		return result
+	}
+
+	var cache config.ReleaseCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("release cache is corrupt: %v", err)
+		result.Hint = "run 'pvm list --all --refresh' to rebuild it"
+		return result
+	}
+
+	ttl, err := config.GetCacheTTL()
+	if err != nil {
+		result.Status = CheckFail
+		result.Message = err.Error()
+		result.Hint = "run 'pvm config list --show-origin' to see where it is set"
+		return result
+	}
+
+	age := time.Since(cache.Timestamp)
+	if age > ttl && ttl != 0 {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("release cache is stale (%s old)", age.Round(time.Minute))
+		result.Hint = "run 'pvm list --all --refresh' to update it"
+		return result
+	}
+
+	result.Status = CheckPass
+	result.Message = fmt.Sprintf("release cache has %d versions (%s old)", len(cache.Versions), age.Round(time.Minute))
+	return result
+}
+
+func checkReleaseSource(ctx context.Context, probe SourceProbe) CheckResult {
+	result := CheckResult{Name: "release source"}
+	kind, location, err := config.GetReleaseSource()
+	if err != nil {
+		result.Status = CheckFail
+		result.Message = err.Error()
+		result.Hint = "run 'pvm config list --show-origin' to see where it is set"
+		return result
+	}
+	name := "GitHub"
+	hint := "check your network connection and proxy settings (HTTPS_PROXY)"
+	if kind != "github" {
+		name = fmt.Sprintf("the %s release source at %s", kind, location)
+		hint = "check release_url and that the source is reachable from this machine"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorHTTPTimeout)
+	defer cancel()
+	err = probe(ctx)
+
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &statusErr) && (statusErr.Code == http.StatusForbidden || statusErr.Code == http.StatusTooManyRequests):
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%s is rate limiting requests (status %d)", name, statusErr.Code)
+		result.Hint = "wait for the rate limit to reset or set github_token_source; cached releases are still usable"
+	case err != nil:
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("%s is unreachable: %v", name, err)
+		result.Hint = hint
+	default:
+		result.Status = CheckPass
+		result.Message = fmt.Sprintf("%s is reachable", name)
+	}
+	return result
+}
+
+func checkDiskSpace() CheckResult {
+	result := CheckResult{Name: "disk space"}
+
+	// The PVM directory may not exist yet; measure the closest existing parent.
+	path := config.GetPVMPath()
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+
+	free, err := freeDiskSpace(path)
+	if err != nil {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("unable to determine free space under %s: %v", path, err)
+		return result
+	}
+
+	if free < minFreeDiskSpace {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("only %s free under %s", formatBytes(free), path)
+		result.Hint = "remove unused versions with 'pvm remove <version>'"
+		return result
+	}
+
+	result.Status = CheckPass
+	result.Message = fmt.Sprintf("%s free under %s", formatBytes(free), path)
+	return result
+}
+
+func checkPermissions() CheckResult {
+	result := CheckResult{Name: "permissions"}
+
+	for _, dir := range []string{config.GetPVMPath(), config.GetVersionsPath(), config.GetBinPath()} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		f, err := os.CreateTemp(dir, ".pvm-doctor-*")
+		if err != nil {
+			result.Status = CheckFail
+			result.Message = fmt.Sprintf("%s is not writable: %v", dir, err)
+			result.Hint = fmt.Sprintf("fix ownership with 'chown -R $USER %s'", config.GetPVMPath())
+			return result
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	result.Status = CheckPass
+	result.Message = fmt.Sprintf("%s is writable", config.GetPVMPath())
+	return result
+}
+
+// formatBytes renders a byte count using binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
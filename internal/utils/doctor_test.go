@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// setupVersionsDir creates a temp dir with the given versions installed and
+// points testConfig at it. Returns the temp dir.
+func setupVersionsDir(t *testing.T, versions []string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	for _, v := range versions {
+		if err := os.MkdirAll(filepath.Join(tmpDir, "versions", v), 0755); err != nil {
+			t.Fatalf("create version dir: %v", err)
+		}
+	}
+	config.SetTestConfig(&config.TestConfig{PVMPath: tmpDir})
+	t.Cleanup(config.ResetConfig)
+	return tmpDir
+}
+
+func TestCheckBinOnPathMissing(t *testing.T) {
+	setupVersionsDir(t, nil)
+	t.Setenv("PATH", t.TempDir())
+
+	if result := checkBinOnPath(); result.Status != CheckFail {
+		t.Errorf("expected fail when bin dir is not on PATH, got %s: %s", result.Status, result.Message)
+	}
+}
+
+func TestCheckBinOnPathShadowed(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	otherDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(otherDir, executableName(config.Pulumi.Binary)), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PATH", otherDir+string(os.PathListSeparator)+binDir)
+
+	if result := checkBinOnPath(); result.Status != CheckWarn {
+		t.Errorf("expected warn when another pulumi shadows the bin dir, got %s: %s", result.Status, result.Message)
+	}
+}
+
+func TestCheckBinOnPathFirst(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	if result := checkBinOnPath(); result.Status != CheckPass {
+		t.Errorf("expected pass, got %s: %s", result.Status, result.Message)
+	}
+}
+
+func TestCheckBinOnPathLinkToPVM(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	tmpDir := setupVersionsDir(t, nil)
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "pulumi"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// A link to pvm's pulumi earlier on PATH runs the same binary.
+	otherDir := t.TempDir()
+	if err := os.Symlink(filepath.Join(binDir, "pulumi"), filepath.Join(otherDir, "pulumi")); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PATH", otherDir+string(os.PathListSeparator)+binDir)
+
+	if result := CheckBinOnPath("pulumi"); result.Status != CheckPass {
+		t.Errorf("expected pass, got %s: %s", result.Status, result.Message)
+	}
+}
+
+func TestCheckSymlinksDangling(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	missing := filepath.Join(tmpDir, "versions", "3.78.1", "pulumi")
+	if err := os.Symlink(missing, filepath.Join(binDir, "pulumi")); err != nil {
+		t.Fatalf("setup symlink: %v", err)
+	}
+
+	if result := checkSymlinks(); result.Status != CheckFail {
+		t.Errorf("expected fail for dangling symlink, got %s: %s", result.Status, result.Message)
+	}
+}
+
+func TestCheckReleaseCache(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	cachePath := filepath.Join(tmpDir, config.CacheFile)
+
+	if result := checkReleaseCache(); result.Status != CheckWarn {
+		t.Errorf("expected warn for missing cache, got %s", result.Status)
+	}
+
+	if err := os.WriteFile(cachePath, []byte("not json"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if result := checkReleaseCache(); result.Status != CheckFail {
+		t.Errorf("expected fail for corrupt cache, got %s", result.Status)
+	}
+
+	data, _ := json.Marshal(config.ReleaseCache{Versions: []string{"3.78.1"}, Timestamp: time.Now().Add(-48 * time.Hour)})
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if result := checkReleaseCache(); result.Status != CheckWarn {
+		t.Errorf("expected warn for stale cache, got %s", result.Status)
+	}
+
+	data, _ = json.Marshal(config.ReleaseCache{Versions: []string{"3.78.1"}, Timestamp: time.Now()})
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if result := checkReleaseCache(); result.Status != CheckPass {
+		t.Errorf("expected pass for fresh cache, got %s", result.Status)
+	}
+}
+
+func TestCheckReleaseSource(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		err  error
+		want CheckStatus
+	}{
+		{nil, CheckPass},
+		{&StatusError{Code: http.StatusTooManyRequests}, CheckWarn},
+		{errors.New("connection refused"), CheckFail},
+	}
+	for _, tc := range tests {
+		probe := func(ctx context.Context) error { return tc.err }
+		if result := checkReleaseSource(ctx, probe); result.Status != tc.want {
+			t.Errorf("probe error %v: expected %s, got %s: %s", tc.err, tc.want, result.Status, result.Message)
+		}
+	}
+
+	// Other sources are probed too.
+	t.Setenv(config.SourceEnvVar, "mirror")
+	t.Setenv(config.SourceURLEnvVar, "http://mirror.invalid")
+	result := checkReleaseSource(ctx, func(ctx context.Context) error { return errors.New("no such host") })
+	if result.Status != CheckFail || !strings.Contains(result.Message, "http://mirror.invalid") {
+		t.Errorf("expected the unreachable mirror to fail, got %s: %s", result.Status, result.Message)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{512, "512 B"},
+		{2048, "2.0 KiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+	}
+	for _, tc := range tests {
+		if got := formatBytes(tc.n); got != tc.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
@@ -1,133 +1,342 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"os/exec"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tomski747/pvm/internal/config"
 )
 
 type githubRelease struct {
-	TagName string `json:"tag_name"`
+	TagName     string        `json:"tag_name"`
+	PublishedAt time.Time     `json:"published_at"`
+	Prerelease  bool          `json:"prerelease"`
+	Draft       bool          `json:"draft"`
+	HTMLURL     string        `json:"html_url"`
+	Body        string        `json:"body"`
+	Assets      []githubAsset `json:"assets"`
 }
 
-// githubAPIURL can be overridden in tests.
-var githubAPIURL = "https://api.github.com/repos/pulumi/pulumi/releases"
+type githubAsset struct {
+	Name string `json:"name"`
+}
 
-// FetchGitHubReleases fetches all available Pulumi versions from GitHub.
-func FetchGitHubReleases(refresh bool) ([]string, error) {
-	// If refresh is true, skip cache and fetch directly from GitHub
-	if !refresh {
-		if versions, err := readCache(); err == nil {
-			return versions, nil
-		}
+// release converts r to a config.Release. Tags with a pre-release suffix
+// count as pre-releases even when GitHub does not flag them.
+func (r githubRelease) release() config.Release {
+	version := strings.TrimPrefix(r.TagName, "v")
+	assets := make([]string, len(r.Assets))
+	for i, asset := range r.Assets {
+		assets[i] = asset.Name
+	}
+	return config.Release{
+		Version:     version,
+		PublishedAt: r.PublishedAt,
+		Prerelease:  r.Prerelease || IsPrerelease(version),
+		Draft:       r.Draft,
+		NotesURL:    r.HTMLURL,
+		Assets:      assets,
 	}
+}
+
+// StatusError is returned when a release source answers with an unexpected
+// HTTP status.
+type StatusError struct {
+	Code int
+}
 
-	versions, err := fetchFromGitHub()
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-200 response code: %d", e.Code)
+}
+
+// githubAPIBaseURL can be overridden in tests.
+var githubAPIBaseURL = config.GithubAPIURL
+
+// GitHubToken returns the GitHub API token found through the
+// github_token_source setting, or an empty string when there is none.
+// Authenticated requests get a much higher rate limit.
+func GitHubToken() (string, error) {
+	source, err := config.GetString("github_token_source")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	switch source {
+	case "env":
+		for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+			if token := os.Getenv(name); token != "" {
+				return token, nil
+			}
+		}
+	case "gh":
+		out, err := exec.Command("gh", "auth", "token").Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get a token from the GitHub CLI: %v", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	return "", nil
+}
 
-	if err := saveCache(versions); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to save cache: %v\n", err)
+// GitHubClient returns an HTTP client that sends token with requests to the
+// GitHub API, and only there. An empty token gives http.DefaultClient.
+func GitHubClient(token string) *http.Client {
+	if token == "" {
+		return http.DefaultClient
 	}
+	return LazyGitHubClient(func() (string, error) { return token, nil })
+}
 
-	return versions, nil
+// LazyGitHubClient returns a client like GitHubClient's whose token is found
+// by calling token when the first request to the GitHub API is made, so
+// commands that never reach the API do not pay for it, e.g. by running the
+// GitHub CLI. An error finding the token fails the request.
+func LazyGitHubClient(token func() (string, error)) *http.Client {
+	return &http.Client{Transport: &tokenTransport{resolve: token, host: apiHost(githubAPIBaseURL)}}
 }
 
-func readCache() ([]string, error) {
-	cachePath := filepath.Join(config.GetPVMPath(), config.CacheFile)
-	data, err := os.ReadFile(cachePath)
+// tokenTransport adds a bearer token to requests for host. The token is
+// resolved once, on the first such request.
+type tokenTransport struct {
+	resolve func() (string, error)
+	host    string
+
+	once  sync.Once
+	token string
+	err   error
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.host && req.Header.Get("Authorization") == "" {
+		t.once.Do(func() { t.token, t.err = t.resolve() })
+		if t.err != nil {
+			return nil, t.err
+		}
+		if t.token != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+t.token)
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// apiHost returns the host of an API base URL.
+func apiHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
 	if err != nil {
-		return nil, err
+		return ""
 	}
+	return u.Host
+}
+
+// GitHubReleasesURL returns the endpoint listing a repo's releases on the
+// GitHub API at baseURL.
+func GitHubReleasesURL(baseURL, repo string) string {
+	return fmt.Sprintf("%s/repos/%s/releases", baseURL, repo)
+}
+
+// githubReleasesURL returns the GitHub API endpoint listing a repo's releases.
+func githubReleasesURL(repo string) string {
+	return GitHubReleasesURL(githubAPIBaseURL, repo)
+}
+
+// githubLatestReleaseURL returns the GitHub API endpoint of a repo's latest release.
+func githubLatestReleaseURL(repo string) string {
+	return githubReleasesURL(repo) + "/latest"
+}
 
-	var cache config.ReleaseCache
-	if err := json.Unmarshal(data, &cache); err != nil {
-		return nil, err
+// FetchGitHubReleases returns every release listed at a GitHub API releases
+// endpoint, following pagination.
+func FetchGitHubReleases(ctx context.Context, client *http.Client, releasesURL string) ([]config.Release, error) {
+	releases, _, err := RefreshGitHubReleases(ctx, client, releasesURL, nil, nil)
+	return releases, err
+}
+
+// RefreshGitHubReleases brings a previously fetched release list up to date.
+// Every page is requested, with the validators recorded in pages, so pages
+// that have not changed cost a 304, which GitHub does not count against the
+// rate limit, and their releases are taken from cached. Pages that changed,
+// whether through new releases or edits to existing ones such as added
+// assets, are listed again. It returns the releases and the pages to keep
+// for the next refresh. With no cached releases every page is fetched
+// unconditionally.
+func RefreshGitHubReleases(ctx context.Context, client *http.Client, releasesURL string, cached []config.Release, pages []config.ReleasePage) ([]config.Release, []config.ReleasePage, error) {
+	known := make(map[string]config.Release, len(cached))
+	for _, release := range cached {
+		known[release.Version] = release
 	}
 
-	if time.Since(cache.Timestamp) > config.CacheTTL {
-		return nil, fmt.Errorf("cache expired")
+	var result []config.Release
+	var newPages []config.ReleasePage
+	seen := make(map[string]bool)
+	perPage := 100
+
+	for page := 1; ; page++ {
+		var previous *config.ReleasePage
+		if page <= len(pages) && len(cached) > 0 {
+			previous = &pages[page-1]
+		}
+
+		url := fmt.Sprintf("%s?page=%d&per_page=%d", releasesURL, page, perPage)
+		releases, validators, notModified, more, err := fetchReleasesPage(ctx, client, url, previous)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if notModified {
+			releases = nil
+			for _, v := range previous.Versions {
+				if release, ok := known[v]; ok {
+					releases = append(releases, release)
+				}
+			}
+			validators = *previous
+		}
+
+		for _, release := range releases {
+			if !seen[release.Version] {
+				seen[release.Version] = true
+				result = append(result, release)
+			}
+		}
+		newPages = append(newPages, validators)
+
+		if len(releases) == 0 || !more {
+			break
+		}
 	}
 
-	return cache.Versions, nil
+	return result, newPages, nil
 }
 
-func saveCache(versions []string) error {
-	cache := config.ReleaseCache{
-		Versions:  versions,
-		Timestamp: time.Now(),
-	}
+// fetchReleasesPage fetches one page of a GitHub releases listing, as a
+// conditional request when previous is set. It returns the releases on the
+// page, the page's validators, whether the server answered 304 Not Modified,
+// and whether there is a next page.
+func fetchReleasesPage(ctx context.Context, client *http.Client, url string, previous *config.ReleasePage) ([]config.Release, config.ReleasePage, bool, bool, error) {
+	var page config.ReleasePage
 
-	sort.Slice(cache.Versions, func(i, j int) bool {
-		return SemverGreater(cache.Versions[i], cache.Versions[j])
-	})
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, page, false, false, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if previous != nil {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
 
-	data, err := json.Marshal(cache)
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, page, false, false, fmt.Errorf("error fetching releases: %v", err)
+	}
+	defer resp.Body.Close()
+
+	more := strings.Contains(resp.Header.Get("Link"), `rel="next"`)
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		return nil, page, true, more, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, page, false, false, &StatusError{Code: resp.StatusCode}
 	}
 
-	cachePath := filepath.Join(config.GetPVMPath(), config.CacheFile)
-	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
-		return err
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, page, false, false, fmt.Errorf("error decoding response: %v", err)
 	}
 
-	return os.WriteFile(cachePath, data, 0644)
+	page.ETag = resp.Header.Get("ETag")
+	page.LastModified = resp.Header.Get("Last-Modified")
+	result := make([]config.Release, len(releases))
+	for i, release := range releases {
+		result[i] = release.release()
+		page.Versions = append(page.Versions, result[i].Version)
+	}
+	return result, page, false, more, nil
 }
 
-func fetchFromGitHub() ([]string, error) {
-	client := &http.Client{}
-	var versions []string
-	page := 1
-	perPage := 100
+// FetchGitHubReleaseNotes returns the release notes of versions listed at a
+// GitHub API releases endpoint, keyed by version. Releases are listed newest
+// first, so pagination stops once every version is found. Versions without
+// a release are left out.
+func FetchGitHubReleaseNotes(ctx context.Context, client *http.Client, releasesURL string, versions []string) (map[string]string, error) {
+	wanted := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		wanted[v] = true
+	}
+	notes := make(map[string]string, len(versions))
 
-	for {
-		url := fmt.Sprintf("%s?page=%d&per_page=%d", githubAPIURL, page, perPage)
-		req, err := http.NewRequest("GET", url, nil)
+	for page := 1; len(notes) < len(wanted); page++ {
+		url := fmt.Sprintf("%s?page=%d&per_page=%d", releasesURL, page, 100)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			return nil, fmt.Errorf("error creating request: %v", err)
 		}
-
 		req.Header.Set("Accept", "application/vnd.github.v3+json")
 		resp, err := client.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("error fetching releases: %v", err)
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
-		}
-
 		var releases []githubRelease
-		if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
-			return nil, fmt.Errorf("error decoding response: %v", err)
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+		} else if err = json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+			err = fmt.Errorf("error decoding response: %v", err)
 		}
-
-		if len(releases) == 0 {
-			break
+		more := strings.Contains(resp.Header.Get("Link"), `rel="next"`)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
 		}
 
 		for _, release := range releases {
-			versions = append(versions, strings.TrimPrefix(release.TagName, "v"))
+			version := strings.TrimPrefix(release.TagName, "v")
+			if wanted[version] {
+				notes[version] = release.Body
+			}
 		}
-
-		if !strings.Contains(resp.Header.Get("Link"), `rel="next"`) {
+		if len(releases) == 0 || !more {
 			break
 		}
+	}
+	return notes, nil
+}
+
+// FetchLatestRelease returns the version of the release a GitHub
+// "releases/latest" API endpoint points at.
+func FetchLatestRelease(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Code: resp.StatusCode}
+	}
 
-		page++
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
 	}
 
-	return versions, nil
+	return strings.TrimPrefix(release.TagName, "v"), nil
 }
 
 // FindLatestMatchingVersion finds the latest version that matches the given prefix.
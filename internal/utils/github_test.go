@@ -1,21 +1,34 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
 )
 
-func TestFetchFromGitHub(t *testing.T) {
+func TestFetchGitHubReleases(t *testing.T) {
 	// Create test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/repos/pulumi/pulumi/releases" {
 			t.Errorf("Expected path /repos/pulumi/pulumi/releases, got %s", r.URL.Path)
 		}
 		releases := []githubRelease{
-			{TagName: "v3.78.1"},
+			{
+				TagName:     "v3.78.1",
+				PublishedAt: time.Date(2023, 8, 10, 0, 0, 0, 0, time.UTC),
+				HTMLURL:     "https://github.com/pulumi/pulumi/releases/tag/v3.78.1",
+				Assets:      []githubAsset{{Name: "pulumi-v3.78.1-linux-x64.tar.gz"}},
+			},
 			{TagName: "v3.78.0"},
+			{TagName: "v3.79.0-alpha.1", Prerelease: true},
+			{TagName: "v3.79.0", Draft: true},
 		}
 		if err := json.NewEncoder(w).Encode(releases); err != nil {
 			t.Errorf("Failed to encode releases: %v", err)
@@ -24,24 +37,182 @@ func TestFetchFromGitHub(t *testing.T) {
 	defer server.Close()
 
 	// Override GitHub API URL for testing
-	originalURL := githubAPIURL
-	githubAPIURL = server.URL + "/repos/pulumi/pulumi/releases"
-	defer func() { githubAPIURL = originalURL }()
+	originalURL := githubAPIBaseURL
+	githubAPIBaseURL = server.URL
+	defer func() { githubAPIBaseURL = originalURL }()
+
+	// Test fetching releases
+	releases, err := FetchGitHubReleases(context.Background(), http.DefaultClient, githubReleasesURL(config.Pulumi.Repo))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expectedVersions := []string{"3.78.1", "3.78.0", "3.79.0-alpha.1", "3.79.0"}
+	if len(releases) != len(expectedVersions) {
+		t.Fatalf("Expected %d releases, got %d", len(expectedVersions), len(releases))
+	}
+
+	for i, release := range releases {
+		if release.Version != expectedVersions[i] {
+			t.Errorf("Expected version %s, got %s", expectedVersions[i], release.Version)
+		}
+	}
+
+	first := releases[0]
+	if first.PublishedAt.Format("2006-01-02") != "2023-08-10" || first.NotesURL == "" {
+		t.Errorf("expected release date and notes URL, got %+v", first)
+	}
+	if !first.HasAsset("pulumi-v3.78.1-linux-x64.tar.gz") || first.HasAsset("pulumi-v3.78.1-windows-x64.zip") {
+		t.Errorf("expected only the linux asset, got %v", first.Assets)
+	}
+	if !releases[2].Prerelease || !releases[3].Draft {
+		t.Errorf("expected pre-release and draft flags, got %+v and %+v", releases[2], releases[3])
+	}
+}
+
+func TestFetchLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(githubRelease{TagName: "v3.78.1"})
+	}))
+	defer server.Close()
 
-	// Test fetching versions
-	versions, err := fetchFromGitHub()
+	version, err := FetchLatestRelease(context.Background(), http.DefaultClient, server.URL)
 	if err != nil {
-		t.Errorf("Expected no error, got: %v", err)
+		t.Fatalf("unexpected error: %v", err)
 	}
+	if version != "3.78.1" {
+		t.Errorf("expected 3.78.1, got %s", version)
+	}
+}
 
-	expectedVersions := []string{"3.78.1", "3.78.0"}
-	if len(versions) != len(expectedVersions) {
-		t.Errorf("Expected %d versions, got %d", len(expectedVersions), len(versions))
+func TestRefreshGitHubReleases(t *testing.T) {
+	pages := map[string][]githubRelease{
+		"1": {{TagName: "v3.78.1"}},
+		"2": {{TagName: "v3.78.0"}},
 	}
+	etags := map[string]string{"1": `"page1-a"`, "2": `"page2-a"`}
+	var requests []string
 
-	for i, version := range versions {
-		if version != expectedVersions[i] {
-			t.Errorf("Expected version %s, got %s", expectedVersions[i], version)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requests = append(requests, page)
+		if page == "1" {
+			w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+		}
+		if r.Header.Get("If-None-Match") == etags[page] {
+			w.WriteHeader(http.StatusNotModified)
+			return
 		}
+		w.Header().Set("ETag", etags[page])
+		_ = json.NewEncoder(w).Encode(pages[page])
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	releases, cachedPages, err := RefreshGitHubReleases(ctx, http.DefaultClient, server.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("RefreshGitHubReleases: %v", err)
+	}
+	if len(releases) != 2 || len(cachedPages) != 2 || cachedPages[0].ETag != `"page1-a"` {
+		t.Fatalf("expected both pages to be fetched, got %v and %v", releases, cachedPages)
+	}
+
+	// Unchanged: every page is requested conditionally and answered with 304.
+	requests = nil
+	again, _, err := RefreshGitHubReleases(ctx, http.DefaultClient, server.URL, releases, cachedPages)
+	if err != nil {
+		t.Fatalf("RefreshGitHubReleases: %v", err)
+	}
+	if strings.Join(requests, ",") != "1,2" || len(again) != 2 {
+		t.Errorf("expected conditional requests for both pages and the cached releases, got %v and %v", requests, again)
+	}
+
+	// A new release on page 1 and an asset added to a release on page 2:
+	// both changed pages are listed again.
+	pages["1"] = []githubRelease{{TagName: "v3.79.0"}, {TagName: "v3.78.1"}}
+	etags["1"] = `"page1-b"`
+	pages["2"] = []githubRelease{{TagName: "v3.78.0", Assets: []githubAsset{{Name: "pulumi-v3.78.0-linux-x64.tar.gz"}}}}
+	etags["2"] = `"page2-b"`
+	requests = nil
+	updated, updatedPages, err := RefreshGitHubReleases(ctx, http.DefaultClient, server.URL, releases, cachedPages)
+	if err != nil {
+		t.Fatalf("RefreshGitHubReleases: %v", err)
+	}
+	if strings.Join(requests, ",") != "1,2" {
+		t.Errorf("expected every page to be requested, got requests for pages %v", requests)
+	}
+	versions := make([]string, len(updated))
+	for i, release := range updated {
+		versions[i] = release.Version
+	}
+	if strings.Join(versions, ",") != "3.79.0,3.78.1,3.78.0" {
+		t.Errorf("expected [3.79.0 3.78.1 3.78.0], got %v", versions)
+	}
+	if !updated[2].HasAsset("pulumi-v3.78.0-linux-x64.tar.gz") {
+		t.Error("expected the asset added to 3.78.0 to be picked up")
+	}
+	if len(updatedPages) != 2 || updatedPages[0].ETag != `"page1-b"` || updatedPages[1].ETag != `"page2-b"` {
+		t.Errorf("expected both refetched pages to be recorded, got %v", updatedPages)
+	}
+}
+
+func TestGitHubClientSendsTokenToAPIOnly(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	originalURL := githubAPIBaseURL
+	githubAPIBaseURL = server.URL
+	defer func() { githubAPIBaseURL = originalURL }()
+
+	client := GitHubClient("secret")
+	resp, err := client.Get(server.URL + "/repos/pulumi/pulumi/releases")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+	resp, err = client.Get(other.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(authorization) != 2 || authorization[0] != "Bearer secret" || authorization[1] != "" {
+		t.Errorf("expected the token to be sent to the API only, got %q", authorization)
+	}
+	if GitHubClient("") != http.DefaultClient {
+		t.Error("expected the default client without a token")
+	}
+}
+
+func TestLazyGitHubClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	originalURL := githubAPIBaseURL
+	githubAPIBaseURL = server.URL
+	defer func() { githubAPIBaseURL = originalURL }()
+
+	lookups := 0
+	client := LazyGitHubClient(func() (string, error) {
+		lookups++
+		return "", errors.New("gh is not installed")
+	})
+	if lookups != 0 {
+		t.Fatal("expected the token not to be looked up before a request")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(server.URL + "/repos/pulumi/pulumi/releases"); err == nil || !strings.Contains(err.Error(), "gh is not installed") {
+			t.Errorf("expected the token error to fail the request, got %v", err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected the token to be looked up once, got %d lookups", lookups)
 	}
 }
@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// ProfileLine is a line of a shell profile that sets pvm up.
+type ProfileLine struct {
+	Path string
+	// Number is the 1-based line number.
+	Number int
+	Text   string
+}
+
+// ImplodePlan lists everything 'pvm implode' removes.
+type ImplodePlan struct {
+	// Dirs are pvm's directories: installed versions, the bin directory,
+	// plugins, caches and configuration.
+	Dirs []string
+	// PluginLinks are the links pvm made in Pulumi's plugin directory.
+	PluginLinks []string
+	// ProfileLines are the shell profile lines that set pvm up.
+	ProfileLines []ProfileLine
+	// ManualProfileLines refer to pvm but are not removed, because they
+	// are not in a known form or removing them would break a block; the
+	// user has to edit them.
+	ManualProfileLines []ProfileLine
+}
+
+// ShellProfiles returns the shell startup files pvm's setup instructions
+// add lines to.
+func ShellProfiles() []string {
+	home := config.GetHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	return []string{
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".profile"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".zprofile"),
+		filepath.Join(home, ".zshenv"),
+		filepath.Join(configHome, "fish", "config.fish"),
+	}
+}
+
+// ProfileBackupSuffix is appended to the name of a shell profile for the
+// copy kept of it before 'pvm implode' edits it.
+const ProfileBackupSuffix = ".pvm-backup"
+
+// pvmMention matches anything in a shell profile line that refers to pvm.
+var pvmMention = regexp.MustCompile(`\bpvm (init|env)\b|\bPVM_HOME\b`)
+
+// commandSeparator splits a shell line into its commands.
+var commandSeparator = regexp.MustCompile(`;|&&|\|\|`)
+
+// binForms returns the ways a profile may spell the bin directory:
+// literally, relative to the home directory or to PVM_HOME.
+func binForms(binPath, home string) []string {
+	forms := []string{binPath, "$PVM_HOME/bin", "${PVM_HOME}/bin"}
+	if rel, ok := strings.CutPrefix(binPath, home+string(filepath.Separator)); ok && home != "" {
+		forms = append(forms, "~/"+rel, "$HOME/"+rel, "${HOME}/"+rel)
+	}
+	return forms
+}
+
+// pvmProfileLines returns patterns matching the whole of each known form of
+// a line setting pvm up: running 'pvm init', setting PVM_HOME, and adding
+// the bin directory to PATH in POSIX shells and fish.
+func pvmProfileLines(binPath, home string) []*regexp.Regexp {
+	var bins []string
+	for _, form := range binForms(binPath, home) {
+		bins = append(bins, regexp.QuoteMeta(form))
+	}
+	bin := `["']?(` + strings.Join(bins, "|") + `)/?["']?`
+	path := `["']?\$(PATH|\{PATH\})["']?`
+	value := `("[^"]*"|'[^']*'|[^\s;&|'"]+)`
+	patterns := []string{
+		`^eval "?\$\(pvm init( (bash|zsh))?\)"?$`,
+		`^pvm init fish \| source$`,
+		`^(export )?PVM_HOME=` + value + `$`,
+		`^set -[gUx]+ PVM_HOME ` + value + `$`,
+		`^(export )?PATH=["']?(` + strings.Join(bins, "|") + `)/?:\$(PATH|\{PATH\})["']?$`,
+		`^(export )?PATH=["']?\$(PATH|\{PATH\}):(` + strings.Join(bins, "|") + `)/?["']?$`,
+		`^set -[gUx]+ PATH ` + bin + ` ` + path + `$`,
+		`^set -[gUx]+ PATH ` + path + ` ` + bin + `$`,
+		`^fish_add_path( -\w+)* ` + bin + `$`,
+	}
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		res = append(res, regexp.MustCompile(pattern))
+	}
+	return res
+}
+
+// blockDepthChange returns how many shell blocks (if, for, while, case,
+// functions and braces in POSIX shells; begin, switch, function and the
+// like in fish) a profile line opens, minus how many it closes.
+func blockDepthChange(line string) int {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	change := 0
+	for _, command := range commandSeparator.Split(line, -1) {
+		words := strings.Fields(command)
+		for len(words) > 0 && (words[0] == "then" || words[0] == "do" || words[0] == "else") {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+		opensBrace := strings.HasSuffix(words[len(words)-1], "{")
+		switch words[0] {
+		case "if", "for", "while", "until", "case", "begin", "switch":
+			change++
+		case "function":
+			if !opensBrace {
+				change++
+			}
+		case "fi", "done", "esac", "end", "}":
+			change--
+		}
+		if opensBrace {
+			change++
+		}
+	}
+	return change
+}
+
+// FindProfileLines returns the lines of the existing profiles that set pvm
+// up. Lines in a known form (see pvmProfileLines) outside any block can be
+// removed; the other lines referring to pvm or its bin directory, e.g.
+// inside an if block that removing them from would break, are returned as
+// manual for the user to edit.
+func FindProfileLines(profiles []string) (removable, manual []ProfileLine, err error) {
+	binPath := config.GetBinPath()
+	home := config.GetHomeDir()
+	known := pvmProfileLines(binPath, home)
+	forms := binForms(binPath, home)
+
+	for _, path := range profiles {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		depth := 0
+		for i, text := range strings.Split(string(data), "\n") {
+			text = strings.TrimSpace(text)
+			before := depth
+			if depth += blockDepthChange(text); depth < 0 {
+				depth = 0
+			}
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			line := ProfileLine{Path: path, Number: i + 1, Text: text}
+			if before == 0 && depth == 0 && matchesAny(known, text) {
+				removable = append(removable, line)
+			} else if mentionsPVM(text, forms) {
+				manual = append(manual, line)
+			}
+		}
+	}
+	return removable, manual, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsPVM reports whether a profile line refers to pvm or one of the
+// forms of its bin directory.
+func mentionsPVM(text string, forms []string) bool {
+	if pvmMention.MatchString(text) {
+		return true
+	}
+	for _, form := range forms {
+		if strings.Contains(text, form) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeProfileLines deletes lines from their profiles, keeping everything
+// else in them as it was and a copy of each profile with
+// ProfileBackupSuffix. Every line must still read as it did when it was
+// found; otherwise no profile is changed.
+func removeProfileLines(lines []ProfileLine) error {
+	var paths []string
+	byPath := make(map[string]map[int]string)
+	for _, line := range lines {
+		if byPath[line.Path] == nil {
+			byPath[line.Path] = make(map[int]string)
+			paths = append(paths, line.Path)
+		}
+		byPath[line.Path][line.Number] = line.Text
+	}
+
+	type edit struct {
+		path     string
+		mode     os.FileMode
+		original []byte
+		kept     []string
+	}
+	var edits []edit
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		all := strings.Split(string(data), "\n")
+		for number, text := range byPath[path] {
+			if number > len(all) || strings.TrimSpace(all[number-1]) != text {
+				return fmt.Errorf("%s has changed since it was read; run 'pvm implode' again", path)
+			}
+		}
+		var kept []string
+		for i, line := range all {
+			if _, ok := byPath[path][i+1]; !ok {
+				kept = append(kept, line)
+			}
+		}
+		edits = append(edits, edit{path, info.Mode().Perm(), data, kept})
+	}
+
+	for _, e := range edits {
+		if err := os.WriteFile(e.path+ProfileBackupSuffix, e.original, e.mode); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", e.path, err)
+		}
+		if err := os.WriteFile(e.path, []byte(strings.Join(e.kept, "\n")), e.mode); err != nil {
+			return fmt.Errorf("failed to update %s: %v", e.path, err)
+		}
+	}
+	return nil
+}
+
+// PlanImplode finds everything pvm has put on this machine: the directories
+// of the current layout and the user configuration file, pvm's links in
+// Pulumi's plugin directory and the shell profile lines that set pvm up.
+func PlanImplode() (ImplodePlan, error) {
+	var plan ImplodePlan
+
+	layout := config.CurrentLayout()
+	candidates := []string{layout.DataDir, layout.CacheDir, layout.ConfigDir}
+	if path := config.UserConfigPath(); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			candidates = append(candidates, filepath.Dir(path))
+		}
+	}
+	home := filepath.Clean(config.GetHomeDir())
+	seen := make(map[string]bool)
+	for _, dir := range candidates {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if dir == home || dir == filepath.Dir(dir) {
+			return ImplodePlan{}, fmt.Errorf("refusing to remove %s", dir)
+		}
+		if _, err := os.Stat(dir); err == nil {
+			plan.Dirs = append(plan.Dirs, dir)
+		}
+	}
+
+	links, err := managedPluginLinks()
+	if err != nil {
+		return ImplodePlan{}, fmt.Errorf("failed to read plugin directory: %v", err)
+	}
+	for linkPath := range links {
+		plan.PluginLinks = append(plan.PluginLinks, linkPath)
+	}
+	sort.Strings(plan.PluginLinks)
+
+	if plan.ProfileLines, plan.ManualProfileLines, err = FindProfileLines(ShellProfiles()); err != nil {
+		return ImplodePlan{}, err
+	}
+	return plan, nil
+}
+
+// Implode removes everything in plan.
+func Implode(plan ImplodePlan) error {
+	if err := removeProfileLines(plan.ProfileLines); err != nil {
+		return err
+	}
+	for _, link := range plan.PluginLinks {
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", link, err)
+		}
+	}
+	for _, dir := range plan.Dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", dir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestFindProfileLines(t *testing.T) {
+	home := t.TempDir()
+	config.ResetConfig()
+	t.Setenv("HOME", home)
+	t.Setenv("PVM_HOME", filepath.Join(home, ".pvm"))
+	binPath := config.GetBinPath()
+
+	profile := filepath.Join(home, ".bashrc")
+	lines := []string{
+		`eval "$(pvm init bash)"`,
+		`export PVM_HOME=/opt/pvm`,
+		`export PATH="` + binPath + `:$PATH"`,
+		`export PATH=~/.pvm/bin:$PATH`,
+		`export PATH="$HOME/.pulumi/bin:$PATH"`,
+		`# eval "$(pvm init bash)"`,
+		`alias ll='ls -l'`,
+		`alias pe='pvm envoy --debug'`,
+		`if [ -d "$HOME/.pvm/bin" ]; then`,
+		`  export PATH="$HOME/.pvm/bin:$PATH"`,
+		`fi`,
+		`[ -d ~/.pvm ] && eval "$(pvm init bash)"`,
+		`set -gx PATH $HOME/.pvm/bin $PATH`,
+	}
+	if err := os.WriteFile(profile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	removable, manual, err := FindProfileLines([]string{profile})
+	if err != nil {
+		t.Fatalf("FindProfileLines: %v", err)
+	}
+	numbers := func(lines []ProfileLine) []int {
+		var numbers []int
+		for _, line := range lines {
+			numbers = append(numbers, line.Number)
+		}
+		return numbers
+	}
+	if got, want := numbers(removable), []int{1, 2, 3, 4, 13}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected lines %v to be removable, got %v", want, got)
+	}
+	// The if block must stay whole, and the line not in a known form is
+	// left alone too.
+	if got, want := numbers(manual), []int{9, 10, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected lines %v to be left for the user, got %v", want, got)
+	}
+}
+
+func TestImplode(t *testing.T) {
+	home := t.TempDir()
+	tmpDir := filepath.Join(home, ".pvm")
+	config.ResetConfig()
+	t.Setenv("HOME", home)
+	t.Setenv("PVM_HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PULUMI_HOME", filepath.Join(home, ".pulumi"))
+
+	plugin := Plugin{Name: "aws", Version: "6.0.0"}
+	installFakePlugin(t, tmpDir, plugin)
+	if err := LinkPlugin(plugin); err != nil {
+		t.Fatalf("LinkPlugin: %v", err)
+	}
+
+	profile := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(profile, []byte("alias ll='ls -l'\neval \"$(pvm init zsh)\"\nexport EDITOR=vim\n"), 0600); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	lines, _, err := FindProfileLines([]string{profile, filepath.Join(home, "missing")})
+	if err != nil {
+		t.Fatalf("FindProfileLines: %v", err)
+	}
+	if len(lines) != 1 || lines[0].Number != 2 || lines[0].Path != profile {
+		t.Fatalf("expected line 2 to be found, got %+v", lines)
+	}
+
+	plan, err := PlanImplode()
+	if err != nil {
+		t.Fatalf("PlanImplode: %v", err)
+	}
+	if len(plan.Dirs) != 1 || plan.Dirs[0] != filepath.Clean(tmpDir) || len(plan.PluginLinks) != 1 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if len(plan.ProfileLines) != 1 || plan.ProfileLines[0] != lines[0] {
+		t.Fatalf("expected the plan to include %+v, got %+v", lines, plan.ProfileLines)
+	}
+	if err := Implode(plan); err != nil {
+		t.Fatalf("Implode: %v", err)
+	}
+
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Error("expected the pvm directory to be removed")
+	}
+	if IsPluginLinked(plugin) {
+		t.Error("expected the plugin link to be removed")
+	}
+	data, _ := os.ReadFile(profile)
+	if string(data) != "alias ll='ls -l'\nexport EDITOR=vim\n" {
+		t.Errorf("expected only the pvm line to be removed, got %q", data)
+	}
+	if info, _ := os.Stat(profile); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the profile's permissions to be kept, got %v", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(profile + ProfileBackupSuffix); !strings.Contains(string(data), "pvm init zsh") {
+		t.Errorf("expected a backup of the original profile, got %q", data)
+	}
+
+	// A profile edited after it was read is left alone.
+	if err := os.WriteFile(profile, []byte("export EDITOR=vim\neval \"$(pvm init zsh)\"\n"), 0600); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	err = removeProfileLines([]ProfileLine{{Path: profile, Number: 1, Text: `eval "$(pvm init zsh)"`}})
+	if err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("expected a changed profile to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(profile); !strings.HasPrefix(string(data), "export EDITOR=vim\n") {
+		t.Errorf("expected the changed profile to be kept, got %q", data)
+	}
+
+	// A PVM_HOME pointing at the home directory must never be wiped.
+	t.Setenv("PVM_HOME", home)
+	if _, err := PlanImplode(); err == nil {
+		t.Error("expected PlanImplode to refuse the home directory, got nil")
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// migrationMove is a file or directory MigrateLayout moves.
+type migrationMove struct {
+	from, to string
+}
+
+// rename is os.Rename; tests replace it to simulate moves across
+// filesystems.
+var rename = os.Rename
+
+// MigrateLayout moves an installation from one layout to another: versions,
+// bin symlinks and plugins go to the data directory, release caches and the
+// mirror to the cache directory and the configuration file to the config
+// directory.
+// Symlinks in the bin directory and Pulumi's plugin directory are rewritten
+// to point into the new data directory, so the active version and linked
+// plugins survive the move. Nothing is moved if any destination exists.
+// Directories on another filesystem are copied and the originals removed.
+// If a step fails, the moves already made are undone.
+func MigrateLayout(from, to config.Layout) error {
+	if from.DataDir == to.DataDir && from.CacheDir == to.CacheDir && from.ConfigDir == to.ConfigDir {
+		return fmt.Errorf("pvm already uses the %s layout", to.Name)
+	}
+	if _, err := os.Stat(from.DataDir); os.IsNotExist(err) {
+		return fmt.Errorf("nothing to migrate: %s does not exist", from.DataDir)
+	}
+
+	moves, err := planMigration(from, to)
+	if err != nil {
+		return err
+	}
+
+	var done []migrationMove
+	undo := func(err error) error {
+		retargetSymlinks(filepath.Join(to.DataDir, config.BinDir), to.DataDir, from.DataDir)
+		retargetSymlinks(config.GetPulumiPluginsPath(), to.DataDir, from.DataDir)
+		for i := len(done) - 1; i >= 0; i-- {
+			if undoErr := movePath(done[i].to, done[i].from); undoErr != nil {
+				return fmt.Errorf("%v; undoing the migration also failed: %v", err, undoErr)
+			}
+		}
+		return err
+	}
+
+	for _, move := range moves {
+		if err := os.MkdirAll(filepath.Dir(move.to), 0755); err != nil {
+			return undo(fmt.Errorf("failed to create %s: %v", filepath.Dir(move.to), err))
+		}
+		if err := movePath(move.from, move.to); err != nil {
+			return undo(fmt.Errorf("failed to move %s to %s: %v", move.from, move.to, err))
+		}
+		done = append(done, move)
+	}
+
+	if err := retargetSymlinks(filepath.Join(to.DataDir, config.BinDir), from.DataDir, to.DataDir); err != nil {
+		return undo(err)
+	}
+	if err := retargetSymlinks(config.GetPulumiPluginsPath(), from.DataDir, to.DataDir); err != nil {
+		return undo(err)
+	}
+
+	// Remove the old directories once they are empty.
+	for _, dir := range []string{from.CacheDir, from.ConfigDir, from.DataDir} {
+		os.Remove(dir)
+	}
+	return nil
+}
+
+// movePath moves the file or directory at from to to. When they are on
+// different filesystems it is copied and the original removed.
+func movePath(from, to string) error {
+	err := rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(from, to); err != nil {
+		os.RemoveAll(to)
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// copyTree copies the file or directory at src to dst, keeping file modes
+// and recreating symlinks as they are.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyRegularFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyRegularFile copies the contents of src to a new file dst with mode.
+func copyRegularFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// planMigration lists what MigrateLayout moves and checks that none of the
+// destinations exist.
+func planMigration(from, to config.Layout) ([]migrationMove, error) {
+	var moves []migrationMove
+	seen := make(map[string]bool)
+	for _, dir := range []string{from.DataDir, from.CacheDir, from.ConfigDir} {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+		}
+
+		for _, entry := range entries {
+			destDir := to.DataDir
+			switch {
+			case entry.Name() == config.UserConfigFile:
+				destDir = to.ConfigDir
+			case strings.HasSuffix(entry.Name(), ".cache"), entry.Name() == config.MirrorDir:
+				destDir = to.CacheDir
+			}
+
+			move := migrationMove{from: filepath.Join(dir, entry.Name()), to: filepath.Join(destDir, entry.Name())}
+			if move.from == move.to || isWithin(destDir, move.from) {
+				continue
+			}
+			if _, err := os.Lstat(move.to); err == nil {
+				return nil, fmt.Errorf("cannot migrate: %s already exists", move.to)
+			}
+			moves = append(moves, move)
+		}
+	}
+	return moves, nil
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// retargetSymlinks rewrites the symlinks in dir that point into oldRoot to
+// point to the same place under newRoot.
+func retargetSymlinks(dir, oldRoot, newRoot string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+
+	for _, entry := range entries {
+		linkPath := filepath.Join(dir, entry.Name())
+		target, err := os.Readlink(linkPath)
+		if err != nil || !isWithin(target, oldRoot) {
+			continue
+		}
+		rel, _ := filepath.Rel(oldRoot, target)
+		if err := os.Remove(linkPath); err != nil {
+			return fmt.Errorf("failed to remove symlink %s: %v", linkPath, err)
+		}
+		if err := os.Symlink(filepath.Join(newRoot, rel), linkPath); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %v", linkPath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// setupMigration creates a home layout installation with a version, a
+// plugin, release caches, a mirror and a configuration file, linked into the
+// bin directory and Pulumi's plugin directory, and returns it with the xdg
+// layout to migrate it to.
+func setupMigration(t *testing.T) (config.Layout, config.Layout) {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("PULUMI_HOME", filepath.Join(root, "pulumi"))
+	from := config.Layout{Name: config.LayoutHome, DataDir: filepath.Join(root, "pvm"), CacheDir: filepath.Join(root, "pvm"), ConfigDir: filepath.Join(root, "pvm")}
+	to := config.Layout{
+		Name:      config.LayoutXDG,
+		DataDir:   filepath.Join(root, "data", "pvm"),
+		CacheDir:  filepath.Join(root, "cache", "pvm"),
+		ConfigDir: filepath.Join(root, "config", "pvm"),
+	}
+
+	versionDir := filepath.Join(from.DataDir, "versions", "3.78.1")
+	pluginDir := filepath.Join(from.DataDir, "plugins", "resource-aws-v6.0.0")
+	mirrorDir := filepath.Join(from.CacheDir, config.MirrorDir)
+	for _, dir := range []string{versionDir, pluginDir, mirrorDir, filepath.Join(from.DataDir, "bin"), config.GetPulumiPluginsPath()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(versionDir, "pulumi"):                "#!/bin/sh",
+		filepath.Join(from.DataDir, "versions.cache"):      "{}",
+		filepath.Join(from.DataDir, config.UserConfigFile): "color = false\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(versionDir, "pulumi"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	binLink := filepath.Join(from.DataDir, "bin", "pulumi")
+	if err := os.Symlink(filepath.Join(versionDir, "pulumi"), binLink); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	pluginLink := filepath.Join(config.GetPulumiPluginsPath(), "resource-aws-v6.0.0")
+	if err := os.Symlink(pluginDir, pluginLink); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return from, to
+}
+
+// checkMigrated checks that the installation made by setupMigration is
+// entirely in to.
+func checkMigrated(t *testing.T, from, to config.Layout) {
+	t.Helper()
+	for _, path := range []string{
+		filepath.Join(to.DataDir, "versions", "3.78.1", "pulumi"),
+		filepath.Join(to.DataDir, "plugins", "resource-aws-v6.0.0"),
+		filepath.Join(to.CacheDir, "versions.cache"),
+		filepath.Join(to.CacheDir, config.MirrorDir),
+		filepath.Join(to.ConfigDir, config.UserConfigFile),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(from.DataDir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", from.DataDir, err)
+	}
+
+	links := map[string]string{
+		filepath.Join(to.DataDir, "bin", "pulumi"):                          filepath.Join(to.DataDir, "versions", "3.78.1", "pulumi"),
+		filepath.Join(config.GetPulumiPluginsPath(), "resource-aws-v6.0.0"): filepath.Join(to.DataDir, "plugins", "resource-aws-v6.0.0"),
+	}
+	for link, want := range links {
+		if got, err := os.Readlink(link); err != nil || got != want {
+			t.Errorf("expected %s to point to %s, got %q (%v)", link, want, got, err)
+		}
+	}
+}
+
+func TestMigrateLayout(t *testing.T) {
+	from, to := setupMigration(t)
+	if err := MigrateLayout(from, to); err != nil {
+		t.Fatalf("MigrateLayout: %v", err)
+	}
+	checkMigrated(t, from, to)
+}
+
+func TestMigrateLayoutAcrossFilesystems(t *testing.T) {
+	from, to := setupMigration(t)
+	origRename := rename
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { rename = origRename }()
+
+	if err := MigrateLayout(from, to); err != nil {
+		t.Fatalf("MigrateLayout: %v", err)
+	}
+	checkMigrated(t, from, to)
+	info, err := os.Stat(filepath.Join(to.DataDir, "versions", "3.78.1", "pulumi"))
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("expected the copied binary to keep its mode, got %v (%v)", info, err)
+	}
+}
+
+func TestMigrateLayoutUndoesMovesOnFailure(t *testing.T) {
+	from, to := setupMigration(t)
+	binLink := filepath.Join(from.DataDir, "bin", "pulumi")
+	wantTarget, _ := os.Readlink(binLink)
+
+	origRename := rename
+	calls := 0
+	rename = func(oldpath, newpath string) error {
+		calls++
+		if calls == 3 {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EACCES}
+		}
+		return origRename(oldpath, newpath)
+	}
+	defer func() { rename = origRename }()
+
+	if err := MigrateLayout(from, to); err == nil {
+		t.Fatal("expected the failed move to fail the migration")
+	}
+	for _, path := range []string{
+		filepath.Join(from.DataDir, "versions", "3.78.1", "pulumi"),
+		filepath.Join(from.DataDir, "plugins", "resource-aws-v6.0.0"),
+		filepath.Join(from.DataDir, config.UserConfigFile),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be moved back: %v", path, err)
+		}
+	}
+	if got, err := os.Readlink(binLink); err != nil || got != wantTarget {
+		t.Errorf("expected %s to point to %s again, got %q (%v)", binLink, wantTarget, got, err)
+	}
+	entries, _ := os.ReadDir(to.DataDir)
+	if len(entries) != 0 {
+		t.Errorf("expected nothing left in %s, got %v", to.DataDir, entries)
+	}
+}
+
+func TestMigrateLayoutRefusesToOverwrite(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("PULUMI_HOME", filepath.Join(root, "pulumi"))
+	from := config.Layout{Name: config.LayoutHome, DataDir: filepath.Join(root, "pvm"), CacheDir: filepath.Join(root, "pvm"), ConfigDir: filepath.Join(root, "pvm")}
+	to := config.Layout{Name: config.LayoutXDG, DataDir: filepath.Join(root, "data"), CacheDir: filepath.Join(root, "cache"), ConfigDir: filepath.Join(root, "config")}
+
+	for _, dir := range []string{filepath.Join(from.DataDir, "versions", "3.78.1"), filepath.Join(to.DataDir, "versions")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	if err := MigrateLayout(from, to); err == nil {
+		t.Fatal("expected an error when the destination exists")
+	}
+	if _, err := os.Stat(filepath.Join(from.DataDir, "versions", "3.78.1")); err != nil {
+		t.Errorf("expected nothing to be moved: %v", err)
+	}
+}
@@ -1,86 +1,47 @@
 package utils
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
 )
 
 // Mock function types.
 type (
-	mockInstallVersionFunc      func(version string) error
-	mockUseVersionFunc          func(version string) error
-	mockGetLatestVersionFunc    func() (string, error)
-	mockResolveVersionFunc      func(version string) (string, error)
-	mockGetAvailableVersionFunc func(refresh bool) ([]string, error)
+	mockInstallPluginFunc func(ctx context.Context, name, version string) (Plugin, error)
 )
 
 // Mock function variables - set these in tests before calling Execute/RunE.
 var (
-	mockInstallVersionFn      mockInstallVersionFunc
-	mockUseVersionFn          mockUseVersionFunc
-	mockGetLatestVersionFn    mockGetLatestVersionFunc
-	mockResolveVersionFn      mockResolveVersionFunc
-	mockGetAvailableVersionFn mockGetAvailableVersionFunc
+	mockInstallPluginFn mockInstallPluginFunc
 )
 
-// MockVersionOperations replaces network-dependent function variables with
-// no-op stubs (or delegates to the mock*Fn variables when set) and returns
-// a cleanup function that restores the originals.
-func MockVersionOperations(t testing.TB) func() {
+// MockPluginOperations replaces network-dependent function variables with
+// stubs (or delegates to the mock*Fn variables when set) and returns a
+// cleanup function that restores the originals. The InstallPlugin stub
+// creates an empty plugin directory in the pvm plugin store.
+func MockPluginOperations(t testing.TB) func() {
 	t.Helper()
 
-	origInstall := InstallVersion
-	origUse := UseVersion
-	origLatest := GetLatestVersion
-	origResolve := ResolveVersion
-	origAvailable := GetAvailableVersions
-
-	InstallVersion = func(version string) error {
-		if mockInstallVersionFn != nil {
-			return mockInstallVersionFn(version)
-		}
-		return nil
-	}
-
-	UseVersion = func(version string) error {
-		if mockUseVersionFn != nil {
-			return mockUseVersionFn(version)
-		}
-		return nil
-	}
-
-	GetLatestVersion = func() (string, error) {
-		if mockGetLatestVersionFn != nil {
-			return mockGetLatestVersionFn()
-		}
-		return "3.78.1", nil
-	}
+	origInstallPlugin := InstallPlugin
 
-	ResolveVersion = func(version string) (string, error) {
-		if mockResolveVersionFn != nil {
-			return mockResolveVersionFn(version)
+	InstallPlugin = func(ctx context.Context, name, version string) (Plugin, error) {
+		if mockInstallPluginFn != nil {
+			return mockInstallPluginFn(ctx, name, version)
 		}
-		// Default: treat the version string as already resolved
-		return version, nil
-	}
-
-	GetAvailableVersions = func(refresh bool) ([]string, error) {
-		if mockGetAvailableVersionFn != nil {
-			return mockGetAvailableVersionFn(refresh)
+		plugin := Plugin{Name: name, Version: version}
+		if err := os.MkdirAll(filepath.Join(config.GetPluginsPath(), plugin.DirName()), 0755); err != nil {
+			return Plugin{}, err
 		}
-		return []string{"3.78.1", "3.78.0", "3.77.0"}, nil
+		return plugin, nil
 	}
 
 	return func() {
-		InstallVersion = origInstall
-		UseVersion = origUse
-		GetLatestVersion = origLatest
-		ResolveVersion = origResolve
-		GetAvailableVersions = origAvailable
+		InstallPlugin = origInstallPlugin
 		// Clear per-test overrides
-		mockInstallVersionFn = nil
-		mockUseVersionFn = nil
-		mockGetLatestVersionFn = nil
-		mockResolveVersionFn = nil
-		mockGetAvailableVersionFn = nil
+		mockInstallPluginFn = nil
 	}
 }
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// FindPinFile walks up from dir looking for a pin file and returns the path
+// of the closest one, or an empty string when there is none.
+func FindPinFile(dir string) string {
+	return findUp(dir, config.PinFile)
+}
+
+// FindLockFile walks up from dir looking for a pvm.lock file and returns the
+// path of the closest one, or an empty string when there is none.
+func FindLockFile(dir string) string {
+	return findUp(dir, config.LockFile)
+}
+
+// findUp returns the path of the closest file called name in dir or its
+// parents, or an empty string when there is none.
+func findUp(dir, name string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ReadPinFile returns the version recorded in a pin file. Blank lines and
+// lines starting with '#' are ignored; a leading "v" is stripped.
+func ReadPinFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.TrimPrefix(line, "v"), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("pin file %s does not contain a version", path)
+}
+
+// GetPinnedVersion returns the version pinned for dir and the pin file it
+// came from. Both are empty when no pin file applies.
+func GetPinnedVersion(dir string) (string, string, error) {
+	path := FindPinFile(dir)
+	if path == "" {
+		return "", "", nil
+	}
+	version, err := ReadPinFile(path)
+	if err != nil {
+		return "", path, err
+	}
+	return version, path, nil
+}
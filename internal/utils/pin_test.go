@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestGetPinnedVersion(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	pinFile := filepath.Join(root, config.PinFile)
+	if err := os.WriteFile(pinFile, []byte("# pinned for CI\n\nv3.78.1\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	version, path, err := GetPinnedVersion(nested)
+	if err != nil {
+		t.Fatalf("GetPinnedVersion: %v", err)
+	}
+	if version != "3.78.1" {
+		t.Errorf("expected 3.78.1, got %s", version)
+	}
+	if path != pinFile {
+		t.Errorf("expected pin file %s, got %s", pinFile, path)
+	}
+}
+
+func TestGetPinnedVersionEmptyFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, config.PinFile), []byte("\n# nothing\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	if _, _, err := GetPinnedVersion(root); err == nil {
+		t.Error("expected error for pin file without a version, got nil")
+	}
+}
@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// Plugin identifies a Pulumi resource provider plugin.
+type Plugin struct {
+	Name    string
+	Version string
+}
+
+// DirName returns the directory name Pulumi expects the plugin under,
+// e.g. "resource-aws-v6.0.0".
+func (p Plugin) DirName() string {
+	return fmt.Sprintf("resource-%s-v%s", p.Name, p.Version)
+}
+
+func (p Plugin) String() string {
+	return fmt.Sprintf("%s@%s", p.Name, p.Version)
+}
+
+var pluginName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// validatePluginName checks that name can only name a provider, e.g. "aws",
+// and never a path.
+func validatePluginName(name string) error {
+	if !pluginName.MatchString(name) {
+		return fmt.Errorf("invalid plugin name %q: use lowercase letters, digits and dashes, e.g. aws", name)
+	}
+	return nil
+}
+
+// Validate checks that the plugin's name and version are well formed, so
+// they are safe to use in paths.
+func (p Plugin) Validate() error {
+	if err := validatePluginName(p.Name); err != nil {
+		return err
+	}
+	if !IsExactVersion(p.Version) {
+		return fmt.Errorf("invalid version %q of plugin %s: expected a version such as 6.0.0", p.Version, p.Name)
+	}
+	return nil
+}
+
+// ParsePlugin returns the plugin with the given name and version, which may
+// have a "v" prefix, checking that both are well formed.
+func ParsePlugin(name, version string) (Plugin, error) {
+	plugin := Plugin{Name: name, Version: strings.TrimPrefix(version, "v")}
+	return plugin, plugin.Validate()
+}
+
+// parsePluginDir parses a plugin directory name such as "resource-aws-v6.0.0".
+func parsePluginDir(name string) (Plugin, bool) {
+	rest, ok := strings.CutPrefix(name, "resource-")
+	if !ok {
+		return Plugin{}, false
+	}
+	i := strings.LastIndex(rest, "-v")
+	if i <= 0 || i+2 >= len(rest) {
+		return Plugin{}, false
+	}
+	return Plugin{Name: rest[:i], Version: rest[i+2:]}, true
+}
+
+// PluginSets maps a Pulumi CLI version to the plugin versions (by plugin
+// name) that should be active while that CLI version is in use.
+type PluginSets map[string]map[string]string
+
+// Function variables allow tests to inject mocks without build tags.
+var (
+	InstallPlugin          = installPlugin
+	pluginDownloadURLTmpl  = config.PulumiPluginURL
+	pluginChecksumsURLTmpl = config.PulumiPluginChecksumsURL
+)
+
+// installPlugin downloads a resource provider into the pvm plugin store. It
+// does not link it into Pulumi's plugin directory; see LinkPlugin. A version
+// of "latest" is resolved against the provider's GitHub releases. The
+// archive is verified against the checksums published with the release, when
+// there are any. It returns the installed plugin.
+func installPlugin(ctx context.Context, name, version string) (Plugin, error) {
+	if err := validatePluginName(name); err != nil {
+		return Plugin{}, err
+	}
+	version = strings.TrimPrefix(version, "v")
+	if version == "latest" {
+		latest, err := FetchLatestRelease(ctx, http.DefaultClient, githubLatestReleaseURL("pulumi/pulumi-"+name))
+		if err != nil {
+			return Plugin{}, fmt.Errorf("failed to get latest version of %s: %w", name, err)
+		}
+		version = latest
+	}
+	plugin, err := ParsePlugin(name, version)
+	if err != nil {
+		return Plugin{}, err
+	}
+
+	pluginDir := filepath.Join(config.GetPluginsPath(), plugin.DirName())
+	if _, err := os.Stat(pluginDir); err == nil {
+		return plugin, nil
+	}
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		return Plugin{}, fmt.Errorf("failed to create plugin directory: %v", err)
+	}
+
+	goos, arch := config.GetPlatformInfo()
+	downloadURL := fmt.Sprintf(pluginDownloadURLTmpl, name, version, name, version, goos, arch)
+	checksum, err := pluginChecksum(ctx, plugin, fmt.Sprintf(config.PulumiPluginAsset, name, version, goos, arch))
+	if err != nil {
+		os.RemoveAll(pluginDir)
+		return Plugin{}, fmt.Errorf("failed to get the checksum of %s: %w", plugin, err)
+	}
+
+	// Provider archives contain the plugin binary at the top level.
+	if err := DownloadAndExtract(ctx, http.DefaultClient, downloadURL, pluginDir, false, 0, checksum); err != nil {
+		os.RemoveAll(pluginDir) // clean up partial download
+		return Plugin{}, fmt.Errorf("failed to download and extract %s: %w", plugin, err)
+	}
+
+	return plugin, nil
+}
+
+// pluginChecksum returns the SHA-256 of a provider archive from the
+// checksums file published with the plugin's release, or an empty string
+// when the release has none or it does not list asset.
+func pluginChecksum(ctx context.Context, plugin Plugin, asset string) (string, error) {
+	url := fmt.Sprintf(pluginChecksumsURLTmpl, plugin.Name, plugin.Version, plugin.Name, plugin.Version)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksums: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksums: received non-200 status code: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksums: %v", err)
+	}
+	return ParseChecksums(data, asset), nil
+}
+
+// GetInstalledPlugins returns the plugins in the pvm plugin store, sorted by
+// name and then newest version first.
+func GetInstalledPlugins() ([]Plugin, error) {
+	files, err := os.ReadDir(config.GetPluginsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var plugins []Plugin
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if plugin, ok := parsePluginDir(file.Name()); ok {
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		if plugins[i].Name != plugins[j].Name {
+			return plugins[i].Name < plugins[j].Name
+		}
+		return SemverGreater(plugins[i].Version, plugins[j].Version)
+	})
+	return plugins, nil
+}
+
+// isPluginInstalled reports whether plugin is in the pvm plugin store.
+func isPluginInstalled(plugin Plugin) bool {
+	info, err := os.Stat(filepath.Join(config.GetPluginsPath(), plugin.DirName()))
+	return err == nil && info.IsDir()
+}
+
+// managedPluginLinks returns the plugins pvm has linked into Pulumi's plugin
+// directory, keyed by their link path. Plugins Pulumi installed itself are
+// never included.
+func managedPluginLinks() (map[string]Plugin, error) {
+	pulumiPlugins := config.GetPulumiPluginsPath()
+	files, err := os.ReadDir(pulumiPlugins)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	storePath := filepath.Clean(config.GetPluginsPath()) + string(filepath.Separator)
+	links := make(map[string]Plugin)
+	for _, file := range files {
+		linkPath := filepath.Join(pulumiPlugins, file.Name())
+		target, err := os.Readlink(linkPath)
+		if err != nil || !strings.HasPrefix(filepath.Clean(target), storePath) {
+			continue
+		}
+		if plugin, ok := parsePluginDir(file.Name()); ok {
+			links[linkPath] = plugin
+		}
+	}
+	return links, nil
+}
+
+// IsPluginLinked reports whether plugin is currently linked into Pulumi's
+// plugin directory by pvm.
+func IsPluginLinked(plugin Plugin) bool {
+	links, err := managedPluginLinks()
+	if err != nil {
+		return false
+	}
+	for _, linked := range links {
+		if linked == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkPlugin makes an installed plugin visible to Pulumi, replacing any other
+// version of the same plugin that pvm linked earlier.
+func LinkPlugin(plugin Plugin) error {
+	if err := plugin.Validate(); err != nil {
+		return err
+	}
+	if !isPluginInstalled(plugin) {
+		return fmt.Errorf("plugin %s is not installed", plugin)
+	}
+
+	if err := unlinkPlugin(plugin.Name); err != nil {
+		return err
+	}
+
+	pulumiPlugins := config.GetPulumiPluginsPath()
+	if err := os.MkdirAll(pulumiPlugins, 0755); err != nil {
+		return fmt.Errorf("failed to create plugin directory: %v", err)
+	}
+
+	linkPath := filepath.Join(pulumiPlugins, plugin.DirName())
+	if _, err := os.Lstat(linkPath); err == nil {
+		// Pulumi installed this exact version itself; nothing to do.
+		return nil
+	}
+
+	sourcePath := filepath.Join(config.GetPluginsPath(), plugin.DirName())
+	if err := os.Symlink(sourcePath, linkPath); err != nil {
+		return fmt.Errorf("failed to create symlink for %s: %v", plugin, err)
+	}
+	return nil
+}
+
+// unlinkPlugin removes every pvm-managed link for the named plugin.
+func unlinkPlugin(name string) error {
+	links, err := managedPluginLinks()
+	if err != nil {
+		return fmt.Errorf("failed to read plugin directory: %v", err)
+	}
+	for linkPath, linked := range links {
+		if linked.Name != name {
+			continue
+		}
+		if err := os.Remove(linkPath); err != nil {
+			return fmt.Errorf("failed to remove existing symlink %s: %v", filepath.Base(linkPath), err)
+		}
+	}
+	return nil
+}
+
+// RemovePlugin unlinks and deletes an installed plugin and drops it from
+// every plugin set.
+func RemovePlugin(plugin Plugin) error {
+	if err := plugin.Validate(); err != nil {
+		return err
+	}
+	if !isPluginInstalled(plugin) {
+		return fmt.Errorf("plugin %s is not installed", plugin)
+	}
+
+	if IsPluginLinked(plugin) {
+		if err := os.Remove(filepath.Join(config.GetPulumiPluginsPath(), plugin.DirName())); err != nil {
+			return fmt.Errorf("failed to remove symlink for %s: %w", plugin, err)
+		}
+	}
+
+	if err := os.RemoveAll(filepath.Join(config.GetPluginsPath(), plugin.DirName())); err != nil {
+		return fmt.Errorf("failed to remove plugin %s: %w", plugin, err)
+	}
+
+	sets, err := LoadPluginSets()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for cliVersion, set := range sets {
+		if set[plugin.Name] == plugin.Version {
+			delete(set, plugin.Name)
+			if len(set) == 0 {
+				delete(sets, cliVersion)
+			}
+			changed = true
+		}
+	}
+	if changed {
+		return savePluginSets(sets)
+	}
+	return nil
+}
+
+// LoadPluginSets reads the plugin sets associated with CLI versions.
+func LoadPluginSets() (PluginSets, error) {
+	sets := make(PluginSets)
+	data, err := os.ReadFile(filepath.Join(config.GetPVMPath(), config.PluginSetsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sets, nil
+		}
+		return nil, fmt.Errorf("failed to read plugin sets: %v", err)
+	}
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin sets: %v", err)
+	}
+	return sets, nil
+}
+
+func savePluginSets(sets PluginSets) error {
+	data, err := json.MarshalIndent(sets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.GetPVMPath(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.GetPVMPath(), config.PluginSetsFile), data, 0644)
+}
+
+// AddPluginToSet associates plugin with a CLI version, replacing any other
+// version of the same plugin in that version's set.
+func AddPluginToSet(cliVersion string, plugin Plugin) error {
+	sets, err := LoadPluginSets()
+	if err != nil {
+		return err
+	}
+	if sets[cliVersion] == nil {
+		sets[cliVersion] = make(map[string]string)
+	}
+	sets[cliVersion][plugin.Name] = plugin.Version
+	return savePluginSets(sets)
+}
+
+// SyncPlugins links the plugin set associated with cliVersion into Pulumi's
+// plugin directory, installing any plugin that is missing. Linked plugins
+// that belong to another version's set but not to this one are unlinked;
+// plugins in no set are left alone.
+func SyncPlugins(ctx context.Context, cliVersion string) error {
+	sets, err := LoadPluginSets()
+	if err != nil {
+		return err
+	}
+	set := sets[cliVersion]
+
+	links, err := managedPluginLinks()
+	if err != nil {
+		return fmt.Errorf("failed to read plugin directory: %v", err)
+	}
+	for linkPath, linked := range links {
+		if _, ok := set[linked.Name]; ok || !sets.contains(linked) {
+			continue
+		}
+		if err := os.Remove(linkPath); err != nil {
+			return fmt.Errorf("failed to unlink plugin %s: %v", linked, err)
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		plugin := Plugin{Name: name, Version: set[name]}
+		if !isPluginInstalled(plugin) {
+			if _, err := InstallPlugin(ctx, plugin.Name, plugin.Version); err != nil {
+				return err
+			}
+		}
+		if err := LinkPlugin(plugin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contains reports whether plugin is in the set of any CLI version.
+func (sets PluginSets) contains(plugin Plugin) bool {
+	for _, set := range sets {
+		if set[plugin.Name] == plugin.Version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// buildFlatTarGz creates a tar.gz archive with files at the top level, the
+// way provider plugin releases are packaged.
+func buildFlatTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("tar write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("tar write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// installFakePlugin creates a plugin directory in the pvm plugin store.
+func installFakePlugin(t *testing.T, tmpDir string, plugin Plugin) {
+	t.Helper()
+	dir := filepath.Join(tmpDir, "plugins", plugin.DirName())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+}
+
+func TestParsePluginDir(t *testing.T) {
+	tests := []struct {
+		name string
+		want Plugin
+		ok   bool
+	}{
+		{"resource-aws-v6.0.0", Plugin{Name: "aws", Version: "6.0.0"}, true},
+		{"resource-azure-native-v2.1.0", Plugin{Name: "azure-native", Version: "2.1.0"}, true},
+		{"language-python-v3.0.0", Plugin{}, false},
+		{"resource-aws", Plugin{}, false},
+	}
+	for _, tc := range tests {
+		got, ok := parsePluginDir(tc.name)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parsePluginDir(%q) = %v, %v; want %v, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestInstallPlugin(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+
+	archive := buildFlatTarGz(t, map[string]string{"pulumi-resource-random": "#!/bin/sh"})
+	sum := sha256.Sum256(archive)
+	goos, arch := config.GetPlatformInfo()
+	checksums := hex.EncodeToString(sum[:]) + "  " + fmt.Sprintf(config.PulumiPluginAsset, "random", "4.13.0", goos, arch) + "\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulumi-resource-random-v4.13.0.tar.gz"):
+			_, _ = w.Write(archive)
+		case strings.HasSuffix(r.URL.Path, "/pulumi-random_4.13.0_checksums.txt"):
+			_, _ = w.Write([]byte(checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	origDownload, origChecksums := pluginDownloadURLTmpl, pluginChecksumsURLTmpl
+	pluginDownloadURLTmpl = server.URL + "/%s/%s/pulumi-resource-%s-v%s.tar.gz?%s-%s"
+	pluginChecksumsURLTmpl = server.URL + "/%s/%s/pulumi-%s_%s_checksums.txt"
+	defer func() { pluginDownloadURLTmpl, pluginChecksumsURLTmpl = origDownload, origChecksums }()
+
+	plugin, err := installPlugin(context.Background(), "random", "v4.13.0")
+	if err != nil {
+		t.Fatalf("installPlugin: %v", err)
+	}
+	if plugin != (Plugin{Name: "random", Version: "4.13.0"}) {
+		t.Errorf("unexpected plugin %v", plugin)
+	}
+
+	binary := filepath.Join(tmpDir, "plugins", "resource-random-v4.13.0", "pulumi-resource-random")
+	if _, err := os.Stat(binary); err != nil {
+		t.Errorf("expected plugin binary in the pvm plugin store: %v", err)
+	}
+	if IsPluginLinked(plugin) {
+		t.Error("expected installing not to link the plugin")
+	}
+
+	// An archive that does not match the published checksum is rejected.
+	if err := RemovePlugin(plugin); err != nil {
+		t.Fatalf("RemovePlugin: %v", err)
+	}
+	checksums = strings.Repeat("0", 64) + "  " + fmt.Sprintf(config.PulumiPluginAsset, "random", "4.13.0", goos, arch) + "\n"
+	if _, err := installPlugin(context.Background(), "random", "4.13.0"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+	if isPluginInstalled(plugin) {
+		t.Error("expected nothing to be installed when the checksum does not match")
+	}
+}
+
+func TestParsePlugin(t *testing.T) {
+	if plugin, err := ParsePlugin("aws", "v6.0.0"); err != nil || plugin != (Plugin{Name: "aws", Version: "6.0.0"}) {
+		t.Errorf("ParsePlugin(aws, v6.0.0) = %v, %v", plugin, err)
+	}
+	if _, err := ParsePlugin("azure-native", "2.0.0-alpha.1"); err != nil {
+		t.Errorf("expected a pre-release to be accepted, got %v", err)
+	}
+	for _, spec := range [][2]string{
+		{"aws", "1/../../.."},
+		{"aws", "6.0"},
+		{"../aws", "6.0.0"},
+		{"AWS", "6.0.0"},
+		{"aws/x", "6.0.0"},
+		{"", "6.0.0"},
+	} {
+		if _, err := ParsePlugin(spec[0], spec[1]); err == nil {
+			t.Errorf("expected ParsePlugin(%q, %q) to fail", spec[0], spec[1])
+		}
+	}
+}
+
+func TestRemovePluginRejectsPaths(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	victim := filepath.Join(tmpDir, "keep")
+	if err := os.MkdirAll(victim, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := RemovePlugin(Plugin{Name: "aws", Version: "1/../../keep"}); err == nil {
+		t.Error("expected a version with path separators to be rejected")
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Error("expected nothing outside the plugin store to be removed")
+	}
+}
+
+func TestLinkPluginSwitchesVersions(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+
+	v5 := Plugin{Name: "aws", Version: "5.42.0"}
+	v6 := Plugin{Name: "aws", Version: "6.0.0"}
+	installFakePlugin(t, tmpDir, v5)
+	installFakePlugin(t, tmpDir, v6)
+
+	if err := LinkPlugin(v5); err != nil {
+		t.Fatalf("LinkPlugin: %v", err)
+	}
+	if err := LinkPlugin(v6); err != nil {
+		t.Fatalf("LinkPlugin: %v", err)
+	}
+
+	if IsPluginLinked(v5) {
+		t.Error("expected aws 5.42.0 to be unlinked")
+	}
+	if !IsPluginLinked(v6) {
+		t.Error("expected aws 6.0.0 to be linked")
+	}
+}
+
+func TestSyncPluginsSwitchesSets(t *testing.T) {
+	tmpDir := setupVersionsDir(t, []string{"3.78.1", "3.90.0"})
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+
+	v5 := Plugin{Name: "aws", Version: "5.42.0"}
+	v6 := Plugin{Name: "aws", Version: "6.0.0"}
+	installFakePlugin(t, tmpDir, v5)
+	installFakePlugin(t, tmpDir, v6)
+	if err := AddPluginToSet("3.78.1", v5); err != nil {
+		t.Fatalf("AddPluginToSet: %v", err)
+	}
+	if err := AddPluginToSet("3.90.0", v6); err != nil {
+		t.Fatalf("AddPluginToSet: %v", err)
+	}
+
+	if err := SyncPlugins(context.Background(), "3.78.1"); err != nil {
+		t.Fatalf("SyncPlugins: %v", err)
+	}
+	if !IsPluginLinked(v5) || IsPluginLinked(v6) {
+		t.Error("expected only aws 5.42.0 to be linked with Pulumi 3.78.1")
+	}
+
+	if err := SyncPlugins(context.Background(), "3.90.0"); err != nil {
+		t.Fatalf("SyncPlugins: %v", err)
+	}
+	if IsPluginLinked(v5) || !IsPluginLinked(v6) {
+		t.Error("expected only aws 6.0.0 to be linked with Pulumi 3.90.0")
+	}
+
+	// Set members the new version's set lacks are unlinked; plugins in no
+	// set stay linked.
+	gcp := Plugin{Name: "gcp", Version: "7.0.0"}
+	random := Plugin{Name: "random", Version: "4.13.0"}
+	for _, plugin := range []Plugin{gcp, random} {
+		installFakePlugin(t, tmpDir, plugin)
+		if err := LinkPlugin(plugin); err != nil {
+			t.Fatalf("LinkPlugin: %v", err)
+		}
+	}
+	if err := AddPluginToSet("3.90.0", gcp); err != nil {
+		t.Fatalf("AddPluginToSet: %v", err)
+	}
+	if err := SyncPlugins(context.Background(), "3.78.1"); err != nil {
+		t.Fatalf("SyncPlugins: %v", err)
+	}
+	if IsPluginLinked(gcp) {
+		t.Error("expected gcp 7.0.0 to be unlinked when switching to a set without it")
+	}
+	if !IsPluginLinked(random) {
+		t.Error("expected random 4.13.0, which is in no set, to stay linked")
+	}
+}
+
+func TestRemovePlugin(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	t.Setenv("PULUMI_HOME", filepath.Join(tmpDir, "pulumi-home"))
+
+	plugin := Plugin{Name: "random", Version: "4.13.0"}
+	installFakePlugin(t, tmpDir, plugin)
+	if err := AddPluginToSet("3.78.1", plugin); err != nil {
+		t.Fatalf("AddPluginToSet: %v", err)
+	}
+	if err := LinkPlugin(plugin); err != nil {
+		t.Fatalf("LinkPlugin: %v", err)
+	}
+
+	if err := RemovePlugin(plugin); err != nil {
+		t.Fatalf("RemovePlugin: %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(tmpDir, "pulumi-home", "plugins", plugin.DirName())); !os.IsNotExist(err) {
+		t.Error("expected plugin link to be removed")
+	}
+	sets, err := LoadPluginSets()
+	if err != nil {
+		t.Fatalf("LoadPluginSets: %v", err)
+	}
+	if len(sets) != 0 {
+		t.Errorf("expected plugin to be dropped from sets, got %v", sets)
+	}
+
+	if err := RemovePlugin(plugin); err == nil {
+		t.Error("expected error removing a plugin that is not installed, got nil")
+	}
+}
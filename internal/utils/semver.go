@@ -1,10 +1,22 @@
 package utils
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
+// exactVersion matches a full semantic version such as "3.78.1" or
+// "3.79.0-alpha.1", which is safe to use as a path component.
+var exactVersion = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// IsExactVersion reports whether v is a full semantic version, e.g. "3.78.1",
+// rather than a prefix, constraint or path.
+func IsExactVersion(v string) bool {
+	return exactVersion.MatchString(v)
+}
+
 // SemverGreater reports whether v1 is semantically greater than v2.
 // Segments are compared numerically left to right; a longer version wins when
 // all shared segments are equal (e.g. "3.1.1" > "3.1").
@@ -20,3 +32,92 @@ func SemverGreater(v1, v2 string) bool {
 	}
 	return len(p1) > len(p2)
 }
+
+// IsPrerelease reports whether v carries a pre-release suffix such as
+// "-alpha.1" or "-rc.2".
+func IsPrerelease(v string) bool {
+	return strings.Contains(v, "-")
+}
+
+// compareVersions compares two versions segment by segment, treating
+// missing segments as zero, and returns -1, 0 or 1.
+func compareVersions(v1, v2 string) int {
+	p1 := strings.Split(v1, ".")
+	p2 := strings.Split(v2, ".")
+	for k := 0; k < len(p1) || k < len(p2); k++ {
+		var n1, n2 int
+		if k < len(p1) {
+			n1, _ = strconv.Atoi(p1[k])
+		}
+		if k < len(p2) {
+			n2, _ = strconv.Atoi(p2[k])
+		}
+		if n1 != n2 {
+			if n1 > n2 {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// isNumericVersion reports whether v is made of dot-separated numbers, e.g.
+// "3", "3.80" or "3.80.1".
+func isNumericVersion(v string) bool {
+	for _, segment := range strings.Split(v, ".") {
+		if segment == "" || strings.Trim(segment, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesConstraint reports whether version satisfies constraint, a comma
+// separated list of comparisons that must all hold, e.g. ">=3.80, <4".
+// Comparisons use >=, >, <=, <, = or !=; a bare version is a prefix, so
+// "3.80" matches 3.80.0 and 3.80.1.
+func MatchesConstraint(version, constraint string) (bool, error) {
+	clauses := strings.Split(constraint, ",")
+	for _, clause := range clauses {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			return false, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+
+		op := ""
+		for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(clause, candidate) {
+				op = candidate
+				break
+			}
+		}
+		want := strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(clause, op)), "v")
+		if !isNumericVersion(want) {
+			return false, fmt.Errorf("invalid version constraint %q: %q is not a version such as 3.80 or 3.80.1", constraint, want)
+		}
+
+		cmp := compareVersions(version, want)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = version == want || strings.HasPrefix(version, want+".")
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -24,3 +24,40 @@ func TestSemverGreater(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchesConstraint(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+	}{
+		{"3.80.0", ">=3.80", true},
+		{"3.79.9", ">=3.80", false},
+		{"3.100.0", ">=3.80", true},
+		{"3.80.1", ">=3.80, <3.81", true},
+		{"3.81.0", ">=3.80, <3.81", false},
+		{"3.80.1", "3.80", true},
+		{"3.8.1", "3.80", false},
+		{"3.80.0", "=3.80", true},
+		{"3.80.0", "!=3.80.0", false},
+		{"4.0.0", ">v3.99.9", true},
+		{"3.78.1", "<=3.78.1", true},
+	}
+
+	for _, tc := range tests {
+		got, err := MatchesConstraint(tc.version, tc.constraint)
+		if err != nil {
+			t.Errorf("MatchesConstraint(%q, %q): %v", tc.version, tc.constraint, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("MatchesConstraint(%q, %q) = %v, want %v", tc.version, tc.constraint, got, tc.want)
+		}
+	}
+
+	// A typo must not silently match every release.
+	for _, constraint := range []string{"", ">=", "3.80,", ">=3.8O", ">=abc", "3.80.x", ">=3..80"} {
+		if _, err := MatchesConstraint("3.80.0", constraint); err == nil {
+			t.Errorf("expected an error for constraint %q", constraint)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// SupportedShells lists the shells 'pvm init' and 'pvm env' can emit code for.
+var SupportedShells = []string{"bash", "zsh", "fish"}
+
+// DetectShell returns the name of the user's shell based on $SHELL, falling
+// back to bash.
+func DetectShell() string {
+	name := filepath.Base(os.Getenv("SHELL"))
+	for _, shell := range SupportedShells {
+		if name == shell {
+			return shell
+		}
+	}
+	return "bash"
+}
+
+func checkShell(shell string) error {
+	for _, s := range SupportedShells {
+		if s == shell {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(SupportedShells, ", "))
+}
+
+// shellQuote quotes s as a single-quoted POSIX or fish string.
+func shellQuote(shell, s string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// BuildPathList removes any pvm version directories from the PATH entries in
+// pathEnv and, when versionDir is not empty, puts versionDir first.
+func BuildPathList(pathEnv, versionDir string) []string {
+	versionsPath := filepath.Clean(config.GetVersionsPath()) + string(filepath.Separator)
+
+	var entries []string
+	if versionDir != "" {
+		entries = append(entries, versionDir)
+	}
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" || strings.HasPrefix(filepath.Clean(dir)+string(filepath.Separator), versionsPath) {
+			continue
+		}
+		entries = append(entries, dir)
+	}
+	return entries
+}
+
+// ShellEnvScript returns code that, when evaluated by shell, puts versionDir
+// first on PATH for the current session. An empty version restores the
+// globally selected version by dropping any per-shell selection.
+func ShellEnvScript(shell, version, versionDir string) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+
+	entries := BuildPathList(os.Getenv("PATH"), versionDir)
+
+	var b strings.Builder
+	if shell == "fish" {
+		quoted := make([]string, len(entries))
+		for i, entry := range entries {
+			quoted[i] = shellQuote(shell, entry)
+		}
+		fmt.Fprintf(&b, "set -gx PATH %s;\n", strings.Join(quoted, " "))
+		if version != "" {
+			fmt.Fprintf(&b, "set -gx %s %s;\n", config.VersionEnvVar, shellQuote(shell, version))
+		} else {
+			fmt.Fprintf(&b, "set -e %s;\n", config.VersionEnvVar)
+		}
+		return b.String(), nil
+	}
+
+	fmt.Fprintf(&b, "export PATH=%s;\n", shellQuote(shell, strings.Join(entries, string(os.PathListSeparator))))
+	if version != "" {
+		fmt.Fprintf(&b, "export %s=%s;\n", config.VersionEnvVar, shellQuote(shell, version))
+	} else {
+		fmt.Fprintf(&b, "unset %s;\n", config.VersionEnvVar)
+	}
+	return b.String(), nil
+}
+
+const posixPathScript = `case ":$PATH:" in
+  *:%[1]s:*) ;;
+  *) export PATH=%[1]s:"$PATH" ;;
+esac
+`
+
+const bashHookScript = `
+_pvm_hook() {
+  if [ "$PWD" != "$_PVM_LAST_PWD" ]; then
+    _PVM_LAST_PWD="$PWD"
+    eval "$(command pvm env --shell bash)"
+  fi
+}
+case ";${PROMPT_COMMAND:-};" in
+  *";_pvm_hook;"*) ;;
+  *) PROMPT_COMMAND="_pvm_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`
+
+const zshHookScript = `
+_pvm_hook() {
+  eval "$(command pvm env --shell zsh)"
+}
+autoload -U add-zsh-hook
+add-zsh-hook chpwd _pvm_hook
+_pvm_hook
+`
+
+const fishPathScript = `if not contains %[1]s $PATH
+  set -gx PATH %[1]s $PATH
+end
+`
+
+const fishHookScript = `
+function _pvm_hook --on-variable PWD
+  command pvm env --shell fish | source
+end
+_pvm_hook
+`
+
+// ShellInitScript returns the code users add to their shell profile: it
+// puts the pvm bin directory on PATH and, when hook is true, switches
+// versions automatically whenever the working directory changes.
+func ShellInitScript(shell string, hook bool) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+
+	binPath := shellQuote(shell, config.GetBinPath())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# pvm shell integration for %s\n", shell)
+	switch shell {
+	case "fish":
+		fmt.Fprintf(&b, fishPathScript, binPath)
+		if hook {
+			b.WriteString(fishHookScript)
+		}
+	case "zsh":
+		fmt.Fprintf(&b, posixPathScript, binPath)
+		if hook {
+			b.WriteString(zshHookScript)
+		}
+	default:
+		fmt.Fprintf(&b, posixPathScript, binPath)
+		if hook {
+			b.WriteString(bashHookScript)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildPathList(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	oldVersion := filepath.Join(tmpDir, "versions", "3.77.0")
+	newVersion := filepath.Join(tmpDir, "versions", "3.78.1")
+	pathEnv := strings.Join([]string{oldVersion, "/usr/bin", "", "/bin"}, string(os.PathListSeparator))
+
+	got := BuildPathList(pathEnv, newVersion)
+	want := []string{newVersion, "/usr/bin", "/bin"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("BuildPathList() = %v, want %v", got, want)
+	}
+
+	got = BuildPathList(pathEnv, "")
+	want = []string{"/usr/bin", "/bin"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("BuildPathList() = %v, want %v", got, want)
+	}
+}
+
+func TestShellEnvScript(t *testing.T) {
+	tmpDir := setupVersionsDir(t, []string{"3.78.1"})
+	versionDir := filepath.Join(tmpDir, "versions", "3.78.1")
+	t.Setenv("PATH", "/usr/bin")
+
+	script, err := ShellEnvScript("bash", "3.78.1", versionDir)
+	if err != nil {
+		t.Fatalf("ShellEnvScript: %v", err)
+	}
+	if !strings.Contains(script, "export PATH='"+versionDir+string(os.PathListSeparator)+"/usr/bin';") {
+		t.Errorf("unexpected PATH export:\n%s", script)
+	}
+	if !strings.Contains(script, "export PVM_VERSION='3.78.1';") {
+		t.Errorf("expected PVM_VERSION export:\n%s", script)
+	}
+
+	script, err = ShellEnvScript("fish", "", "")
+	if err != nil {
+		t.Fatalf("ShellEnvScript: %v", err)
+	}
+	if !strings.Contains(script, "set -gx PATH '/usr/bin';") || !strings.Contains(script, "set -e PVM_VERSION;") {
+		t.Errorf("unexpected fish script:\n%s", script)
+	}
+
+	if _, err := ShellEnvScript("tcsh", "", ""); err == nil {
+		t.Error("expected error for unsupported shell, got nil")
+	}
+}
+
+func TestShellInitScript(t *testing.T) {
+	tmpDir := setupVersionsDir(t, nil)
+	binPath := filepath.Join(tmpDir, "bin")
+
+	for _, shell := range SupportedShells {
+		script, err := ShellInitScript(shell, true)
+		if err != nil {
+			t.Fatalf("ShellInitScript(%s): %v", shell, err)
+		}
+		if !strings.Contains(script, binPath) {
+			t.Errorf("%s: expected bin path in script:\n%s", shell, script)
+		}
+		if !strings.Contains(script, "pvm env --shell "+shell) {
+			t.Errorf("%s: expected directory-change hook in script:\n%s", shell, script)
+		}
+
+		script, _ = ShellInitScript(shell, false)
+		if strings.Contains(script, "_pvm_hook") {
+			t.Errorf("%s: expected no hook without hook=true:\n%s", shell, script)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("bash", "it's"); got != `'it'\''s'` {
+		t.Errorf("bash quote = %s", got)
+	}
+	if got := shellQuote("fish", "it's"); got != `'it\'s'` {
+		t.Errorf("fish quote = %s", got)
+	}
+}
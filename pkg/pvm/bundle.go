@@ -0,0 +1,175 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// BundleChecksumsFile lists the SHA-256 of every archive in a bundle.
+const BundleChecksumsFile = "SHA256SUMS"
+
+// AddToBundle downloads the archives of version for each platform, given as
+// "os/arch", into the bundle directory dir. Archives are verified against
+// the checksums the release source publishes. Their checksums are recorded
+// in dir's SHA256SUMS and the release's metadata in a release cache named
+// like the tool's own, so ImportBundle can install them without network
+// access. It returns the resolved version.
+func (m *Manager) AddToBundle(ctx context.Context, dir, version string, platforms []string) (string, error) {
+	resolvedVersion, err := m.Resolve(ctx, version)
+	if err != nil {
+		return "", err
+	}
+
+	release := Release{Version: resolvedVersion}
+	if releases, err := m.Releases(ctx, false); err == nil {
+		for _, r := range releases {
+			if r.Version == resolvedVersion {
+				release = r
+			}
+		}
+	}
+	release.Assets = nil
+
+	sums, err := readBundleChecksums(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, platform := range platforms {
+		goos, arch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || arch == "" {
+			return "", fmt.Errorf("invalid platform %q (expected os/arch, e.g. linux/amd64)", platform)
+		}
+		url, err := m.source.AssetURL(ctx, m.tool, resolvedVersion, goos, arch)
+		if err != nil {
+			return "", err
+		}
+		checksum, err := m.source.Checksum(ctx, m.tool, resolvedVersion, goos, arch)
+		if err != nil {
+			return "", err
+		}
+
+		asset := m.tool.AssetName(resolvedVersion, goos, arch)
+		sum, err := utils.DownloadFile(ctx, m.client, url, filepath.Join(dir, asset), checksum)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s: %w", asset, err)
+		}
+		sums[asset] = strings.ToLower(sum)
+		release.Assets = append(release.Assets, asset)
+	}
+	if err := writeBundleChecksums(dir, sums); err != nil {
+		return "", err
+	}
+
+	// Merge the release into the bundle's metadata.
+	cachePath := m.tool.CachePath(dir)
+	var releases []Release
+	if cache, err := loadCache(cachePath); err == nil {
+		releases = cache.Releases
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read bundle metadata: %v", err)
+	}
+	merged := false
+	for i, r := range releases {
+		if r.Version == resolvedVersion {
+			release.Assets = append(r.Assets, release.Assets...)
+			releases[i] = release
+			merged = true
+		}
+	}
+	if !merged {
+		releases = append(releases, release)
+	}
+	if err := saveCache(cachePath, releases, nil, "", ""); err != nil {
+		return "", fmt.Errorf("failed to write bundle metadata: %v", err)
+	}
+	return resolvedVersion, nil
+}
+
+// ImportBundle installs every version of the tool in the unpacked bundle
+// directory dir for the Manager's platform, verifying each archive against
+// the bundle's checksums. Versions already installed are left alone. It
+// returns the bundled versions for the platform, newest first, and fails if
+// the bundle has releases of the tool but no archives for the platform.
+func (m *Manager) ImportBundle(ctx context.Context, dir string) ([]string, error) {
+	cache, err := loadCache(m.tool.CachePath(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle metadata: %v", err)
+	}
+	sums, err := readBundleChecksums(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []string
+	for _, release := range cache.Releases {
+		asset := m.tool.AssetName(release.Version, m.goos, m.arch)
+		path := filepath.Join(dir, asset)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		checksum := sums[asset]
+		if checksum == "" {
+			return nil, fmt.Errorf("bundle has no checksum for %s", asset)
+		}
+		if err := m.installArchive(ctx, release.Version, fileURL(path), checksum); err != nil {
+			return nil, fmt.Errorf("failed to install %s %s: %w", m.tool.DisplayName, release.Version, err)
+		}
+		imported = append(imported, release.Version)
+	}
+	if len(imported) == 0 && len(cache.Releases) > 0 {
+		return nil, fmt.Errorf("bundle has no %s archives for %s/%s", m.tool.DisplayName, m.goos, m.arch)
+	}
+
+	sort.Slice(imported, func(i, j int) bool {
+		return utils.SemverGreater(imported[i], imported[j])
+	})
+	return imported, nil
+}
+
+// readBundleChecksums reads the checksums of the archives in the bundle
+// directory dir, keyed by file name.
+func readBundleChecksums(dir string) (map[string]string, error) {
+	sums := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(dir, BundleChecksumsFile))
+	if os.IsNotExist(err) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle checksums: %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+		}
+	}
+	return sums, nil
+}
+
+// writeBundleChecksums writes sums to the bundle directory dir in the
+// "<sha256>  <file name>" format of sha256sum.
+func writeBundleChecksums(dir string, sums map[string]string) error {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	if err := os.WriteFile(filepath.Join(dir, BundleChecksumsFile), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write bundle checksums: %v", err)
+	}
+	return nil
+}
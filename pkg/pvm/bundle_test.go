@@ -0,0 +1,67 @@
+package pvm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for _, version := range []string{"3.78", "3.77.5"} {
+		if _, err := m.AddToBundle(ctx, dir, version, []string{"linux/amd64", "darwin/arm64"}); err != nil {
+			t.Fatalf("AddToBundle(%s): %v", version, err)
+		}
+	}
+	for _, name := range []string{"pulumi-v3.78.1-linux-x64.tar.gz", "pulumi-v3.77.5-darwin-arm64.tar.gz", BundleChecksumsFile, Pulumi.CacheFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s in the bundle: %v", name, err)
+		}
+	}
+
+	target := New(WithRoot(t.TempDir()), WithPlatform("linux", "amd64"))
+	versions, err := target.ImportBundle(ctx, dir)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "3.78.1" || versions[1] != "3.77.5" {
+		t.Errorf("ImportBundle = %v, want [3.78.1 3.77.5]", versions)
+	}
+	if _, err := os.Stat(filepath.Join(target.VersionsDir(), "3.78.1", "pulumi")); err != nil {
+		t.Errorf("expected 3.78.1 to be installed: %v", err)
+	}
+
+	if versions, err := New(WithRoot(t.TempDir()), WithTool(ESC)).ImportBundle(ctx, dir); err != nil || versions != nil {
+		t.Errorf("expected nothing to import for a tool that is not bundled, got %v, %v", versions, err)
+	}
+	if _, err := New(WithRoot(t.TempDir()), WithPlatform("windows", "amd64")).ImportBundle(ctx, dir); err == nil {
+		t.Error("expected an error for a platform the bundle has no archives for")
+	}
+}
+
+func TestImportBundleChecksumMismatch(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if _, err := m.AddToBundle(ctx, dir, "3.78.1", []string{"linux/amd64"}); err != nil {
+		t.Fatalf("AddToBundle: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pulumi-v3.78.1-linux-x64.tar.gz"), []byte("tampered"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	target := New(WithRoot(t.TempDir()), WithPlatform("linux", "amd64"))
+	_, err := target.ImportBundle(ctx, dir)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+	if versions, _ := target.List(); len(versions) != 0 {
+		t.Errorf("expected nothing to be installed, got %v", versions)
+	}
+}
@@ -0,0 +1,75 @@
+package pvm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+func loadCache(path string) (*config.ReleaseCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache config.ReleaseCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+
+	// Caches written before release metadata was recorded only hold versions.
+	if len(cache.Releases) == 0 {
+		for _, v := range cache.Versions {
+			cache.Releases = append(cache.Releases, Release{Version: v, Prerelease: utils.IsPrerelease(v)})
+		}
+	}
+
+	return &cache, nil
+}
+
+// saveCache writes releases to the cache at path, recording the kind and
+// location of the source they came from.
+func saveCache(path string, releases []Release, pages []ReleasePage, kind, location string) error {
+	cache := config.ReleaseCache{
+		Releases:       releases,
+		Pages:          pages,
+		Timestamp:      time.Now(),
+		SourceKind:     kind,
+		SourceLocation: location,
+	}
+
+	// Sort a copy; callers keep the order the source listed releases in.
+	cache.Releases = append([]Release(nil), releases...)
+	sort.Slice(cache.Releases, func(i, j int) bool {
+		return utils.SemverGreater(cache.Releases[i].Version, cache.Releases[j].Version)
+	})
+	cache.Versions = releaseVersions(cache.Releases)
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// releaseVersions returns the versions of releases, leaving out drafts since
+// they cannot be downloaded.
+func releaseVersions(releases []Release) []string {
+	versions := make([]string, 0, len(releases))
+	for _, release := range releases {
+		if !release.Draft {
+			versions = append(versions, release.Version)
+		}
+	}
+	return versions
+}
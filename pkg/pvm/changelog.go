@@ -0,0 +1,66 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// ReleaseNotes is a release and its notes, in the Markdown the release
+// source publishes.
+type ReleaseNotes struct {
+	Release
+	Notes string
+}
+
+// Changelog resolves from and to and returns the notes of the stable
+// releases after from up to and including to, oldest first. to is included
+// even if it is a pre-release. The release source must implement
+// NotesSource.
+func (m *Manager) Changelog(ctx context.Context, from, to string) ([]ReleaseNotes, error) {
+	source, ok := m.source.(NotesSource)
+	if !ok {
+		return nil, fmt.Errorf("the release source does not publish release notes")
+	}
+	from, err := m.Resolve(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	to, err = m.Resolve(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.SemverGreater(to, from) {
+		return nil, fmt.Errorf("%s is not newer than %s", to, from)
+	}
+
+	releases, err := m.Releases(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %v", err)
+	}
+	var changelog []ReleaseNotes
+	var versions []string
+	for _, release := range releases {
+		if release.Draft || (release.Prerelease && release.Version != to) {
+			continue
+		}
+		if utils.SemverGreater(release.Version, from) && !utils.SemverGreater(release.Version, to) {
+			changelog = append(changelog, ReleaseNotes{Release: release})
+			versions = append(versions, release.Version)
+		}
+	}
+	sort.Slice(changelog, func(i, j int) bool {
+		return utils.SemverGreater(changelog[j].Version, changelog[i].Version)
+	})
+
+	notes, err := source.ReleaseNotes(ctx, m.tool, versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release notes: %v", err)
+	}
+	for i := range changelog {
+		changelog[i].Notes = notes[changelog[i].Version]
+	}
+	return changelog, nil
+}
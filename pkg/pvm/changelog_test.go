@@ -0,0 +1,58 @@
+package pvm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChangelog(t *testing.T) {
+	pages := [][]map[string]any{
+		{
+			{"tag_name": "v3.79.0-alpha.1", "prerelease": true, "body": "alpha"},
+			{"tag_name": "v3.78.1", "body": "### Bug Fixes\n- fix b"},
+		},
+		{
+			{"tag_name": "v3.78.0", "body": "### Features\n- feature a"},
+			{"tag_name": "v3.77.0", "body": "old"},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/pulumi/pulumi/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		page := pages[0]
+		if r.URL.Query().Get("page") == "2" {
+			page = pages[1]
+		} else {
+			w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	source := NewGitHubSource(server.Client())
+	source.baseURL = server.URL
+	m := New(WithRoot(t.TempDir()), WithReleaseSource(source))
+
+	changelog, err := m.Changelog(context.Background(), "3.77", "3.78.1")
+	if err != nil {
+		t.Fatalf("Changelog: %v", err)
+	}
+	if len(changelog) != 2 || changelog[0].Version != "3.78.0" || changelog[1].Version != "3.78.1" {
+		t.Fatalf("expected 3.78.0 and 3.78.1, got %+v", changelog)
+	}
+	if changelog[0].Notes != "### Features\n- feature a" || changelog[1].Notes != "### Bug Fixes\n- fix b" {
+		t.Errorf("unexpected notes: %+v", changelog)
+	}
+
+	if _, err := m.Changelog(context.Background(), "3.78.1", "3.78.0"); err == nil {
+		t.Error("expected an error for a range going backwards, got nil")
+	}
+	if _, err := New(WithRoot(t.TempDir()), WithReleaseSource(NewDirSource(t.TempDir()))).Changelog(context.Background(), "3.77.0", "3.78.1"); err == nil {
+		t.Error("expected an error for a source without release notes, got nil")
+	}
+}
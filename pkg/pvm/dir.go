@@ -0,0 +1,97 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// DirSource reads releases from a local directory holding release archives
+// under their original names, e.g. pulumi-v3.78.1-linux-x64.tar.gz, and
+// optionally the checksums files published with them. It suits air-gapped
+// machines and file shares.
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource returns a DirSource reading archives from dir.
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+// assetPattern matches the archive names of tool and captures the version.
+func assetPattern(tool Tool) *regexp.Regexp {
+	pattern := strings.NewReplacer(
+		`\{version\}`, `(.+?)`,
+		`\{os\}`, `[a-z]+`,
+		`\{arch\}`, `[a-z0-9]+`,
+		`\{ext\}`, `(?:tar\.gz|zip)`,
+	).Replace(regexp.QuoteMeta(tool.Asset))
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+// Describe implements DescribedSource.
+func (s *DirSource) Describe() (string, string) {
+	return "dir", s.dir
+}
+
+// Releases implements ReleaseSource.
+func (s *DirSource) Releases(ctx context.Context, tool Tool) ([]Release, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release directory: %v", err)
+	}
+
+	pattern := assetPattern(tool)
+	index := make(map[string]int)
+	var releases []Release
+	for _, file := range files {
+		match := pattern.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+		version := match[1]
+		i, ok := index[version]
+		if !ok {
+			i = len(releases)
+			index[version] = i
+			releases = append(releases, Release{Version: version, Prerelease: utils.IsPrerelease(version)})
+		}
+		releases[i].Assets = append(releases[i].Assets, file.Name())
+	}
+	return releases, nil
+}
+
+// Latest implements ReleaseSource.
+func (s *DirSource) Latest(ctx context.Context, tool Tool) (string, error) {
+	releases, err := s.Releases(ctx, tool)
+	if err != nil {
+		return "", err
+	}
+	return latestStable(releases)
+}
+
+// AssetURL implements ReleaseSource.
+func (s *DirSource) AssetURL(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	path := filepath.Join(s.dir, tool.AssetName(version, goos, arch))
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s %s has no archive for %s-%s in %s", tool.DisplayName, version, goos, arch, s.dir)
+	}
+	return fileURL(path), nil
+}
+
+// Checksum implements ReleaseSource using the checksums file next to the
+// archives, when there is one.
+func (s *DirSource) Checksum(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	name := tool.ChecksumsName(version)
+	if name == "" {
+		return "", nil
+	}
+	return releaseChecksum(ctx, http.DefaultClient, filepath.Join(s.dir, name), tool, version, goos, arch)
+}
@@ -0,0 +1,67 @@
+package pvm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"pulumi-v3.78.1-linux-x64.tar.gz",
+		"pulumi-v3.78.1-darwin-arm64.tar.gz",
+		"pulumi-v3.77.0-windows-x64.zip",
+		"pulumi-3.78.1-checksums.txt",
+		"esc-v0.9.1-linux-x64.tar.gz",
+		"README.md",
+	} {
+		content := "archive"
+		if name == "pulumi-3.78.1-checksums.txt" {
+			content = "abcd  pulumi-v3.78.1-linux-x64.tar.gz\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	source := NewDirSource(dir)
+	ctx := context.Background()
+
+	releases, err := source.Releases(ctx, Pulumi)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	versions := releaseVersions(releases)
+	sort.Strings(versions)
+	if len(versions) != 2 || versions[0] != "3.77.0" || versions[1] != "3.78.1" {
+		t.Errorf("expected [3.77.0 3.78.1], got %v", versions)
+	}
+
+	latest, err := source.Latest(ctx, ESC)
+	if err != nil || latest != "0.9.1" {
+		t.Errorf("Latest = %s, %v; want 0.9.1", latest, err)
+	}
+
+	url, err := source.AssetURL(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("AssetURL: %v", err)
+	}
+	if want := fileURL(filepath.Join(dir, "pulumi-v3.78.1-linux-x64.tar.gz")); url != want {
+		t.Errorf("AssetURL = %s, want %s", url, want)
+	}
+	if _, err := source.AssetURL(ctx, Pulumi, "3.77.0", "linux", "amd64"); err == nil {
+		t.Error("expected error for a missing archive, got nil")
+	}
+
+	sum, err := source.Checksum(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil || sum != "abcd" {
+		t.Errorf("Checksum = %q, %v; want abcd", sum, err)
+	}
+	sum, err = source.Checksum(ctx, Pulumi, "3.77.0", "windows", "amd64")
+	if err != nil || sum != "" {
+		t.Errorf("Checksum = %q, %v; want no checksum", sum, err)
+	}
+}
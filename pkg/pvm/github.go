@@ -0,0 +1,84 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// GitHubSource reads releases from the GitHub API and downloads assets from
+// GitHub release pages.
+type GitHubSource struct {
+	client      *http.Client
+	baseURL     string
+	downloadURL string
+}
+
+// NewGitHubSource returns a GitHubSource that talks to api.github.com with
+// client.
+func NewGitHubSource(client *http.Client) *GitHubSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &GitHubSource{client: client, baseURL: config.GithubAPIURL, downloadURL: config.GithubDownloadURL}
+}
+
+// NewMirrorSource returns a GitHubSource that reads releases from a release
+// mirror exposing the GitHub API and download URL layout at baseURL, such as
+// 'pvm mirror serve'.
+func NewMirrorSource(baseURL string, client *http.Client) *GitHubSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &GitHubSource{client: client, baseURL: baseURL, downloadURL: baseURL + "/%s/releases/download/v%s/%s"}
+}
+
+// Describe implements DescribedSource.
+func (s *GitHubSource) Describe() (string, string) {
+	if s.baseURL == config.GithubAPIURL {
+		return "github", ""
+	}
+	return "mirror", s.baseURL
+}
+
+// Releases implements ReleaseSource.
+func (s *GitHubSource) Releases(ctx context.Context, tool Tool) ([]Release, error) {
+	return utils.FetchGitHubReleases(ctx, s.client, utils.GitHubReleasesURL(s.baseURL, tool.Repo))
+}
+
+// RefreshReleases implements RefreshingSource with a conditional request
+// for every page, so only pages that changed are transferred again.
+func (s *GitHubSource) RefreshReleases(ctx context.Context, tool Tool, cached []Release, pages []ReleasePage) ([]Release, []ReleasePage, error) {
+	return utils.RefreshGitHubReleases(ctx, s.client, utils.GitHubReleasesURL(s.baseURL, tool.Repo), cached, pages)
+}
+
+// ReleaseNotes implements NotesSource with the bodies of the releases.
+func (s *GitHubSource) ReleaseNotes(ctx context.Context, tool Tool, versions []string) (map[string]string, error) {
+	return utils.FetchGitHubReleaseNotes(ctx, s.client, utils.GitHubReleasesURL(s.baseURL, tool.Repo), versions)
+}
+
+// Latest implements ReleaseSource.
+func (s *GitHubSource) Latest(ctx context.Context, tool Tool) (string, error) {
+	return utils.FetchLatestRelease(ctx, s.client, utils.GitHubReleasesURL(s.baseURL, tool.Repo)+"/latest")
+}
+
+// AssetURL implements ReleaseSource.
+func (s *GitHubSource) AssetURL(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	return fmt.Sprintf(s.downloadURL, tool.Repo, version, tool.AssetName(version, goos, arch)), nil
+}
+
+// Checksum implements ReleaseSource using the checksums file attached to
+// the release.
+func (s *GitHubSource) Checksum(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	name := tool.ChecksumsName(version)
+	if name == "" {
+		return "", nil
+	}
+	url := fmt.Sprintf(s.downloadURL, tool.Repo, version, name)
+	return releaseChecksum(ctx, s.client, url, tool, version, goos, arch)
+}
@@ -0,0 +1,121 @@
+package pvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitHubSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/pulumi/esc/releases":
+			_ = json.NewEncoder(w).Encode([]map[string]string{{"tag_name": "v0.9.1"}, {"tag_name": "v0.9.0"}})
+		case "/repos/pulumi/esc/releases/latest":
+			_ = json.NewEncoder(w).Encode(map[string]string{"tag_name": "v0.9.1"})
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := NewGitHubSource(server.Client())
+	source.baseURL = server.URL
+	ctx := context.Background()
+
+	releases, err := source.Releases(ctx, ESC)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	versions := releaseVersions(releases)
+	if len(versions) != 2 || versions[0] != "0.9.1" || versions[1] != "0.9.0" {
+		t.Errorf("expected [0.9.1 0.9.0], got %v", versions)
+	}
+
+	latest, err := source.Latest(ctx, ESC)
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest != "0.9.1" {
+		t.Errorf("expected 0.9.1, got %s", latest)
+	}
+
+	want := "https://github.com/pulumi/esc/releases/download/v0.9.1/esc-v0.9.1-linux-x64.tar.gz"
+	got, err := source.AssetURL(ctx, ESC, "0.9.1", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("AssetURL: %v", err)
+	}
+	if got != want {
+		t.Errorf("AssetURL = %s, want %s", got, want)
+	}
+
+	// ESC does not publish checksums.
+	if sum, err := source.Checksum(ctx, ESC, "0.9.1", "linux", "amd64"); err != nil || sum != "" {
+		t.Errorf("Checksum = %q, %v; want no checksum", sum, err)
+	}
+}
+
+func TestGitHubSourceChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pulumi/pulumi/releases/download/v3.78.1/pulumi-3.78.1-checksums.txt":
+			fmt.Fprintln(w, "aaaa  pulumi-v3.78.1-darwin-arm64.tar.gz")
+			fmt.Fprintln(w, "bbbb  pulumi-v3.78.1-linux-x64.tar.gz")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := NewGitHubSource(server.Client())
+	source.downloadURL = server.URL + "/%s/releases/download/v%s/%s"
+	ctx := context.Background()
+
+	sum, err := source.Checksum(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("Checksum: %v", err)
+	}
+	if sum != "bbbb" {
+		t.Errorf("Checksum = %q, want bbbb", sum)
+	}
+
+	// Old releases without a checksums file are downloaded unverified.
+	sum, err = source.Checksum(ctx, Pulumi, "3.0.0", "linux", "amd64")
+	if err != nil || sum != "" {
+		t.Errorf("Checksum = %q, %v; want no checksum", sum, err)
+	}
+}
+
+func TestManagerRevalidatesGitHubReleases(t *testing.T) {
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_ = json.NewEncoder(w).Encode([]map[string]string{{"tag_name": "v3.78.1"}})
+	}))
+	defer server.Close()
+
+	source := NewGitHubSource(server.Client())
+	source.baseURL = server.URL
+	m := New(WithRoot(t.TempDir()), WithReleaseSource(source))
+	ctx := context.Background()
+
+	if _, err := m.Releases(ctx, true); err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	releases, err := m.Releases(ctx, true)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	if conditional != 1 || len(releases) != 1 || releases[0].Version != "3.78.1" {
+		t.Errorf("expected the refresh to be answered with 304, got %d conditional requests and %v", conditional, releases)
+	}
+}
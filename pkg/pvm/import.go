@@ -0,0 +1,156 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// versionOutput matches the output of "pulumi version" and "esc version",
+// e.g. "v3.91.1".
+var versionOutput = regexp.MustCompile(`^v?(\d+\.\d+\.\d+\S*)`)
+
+// packageManagerDirs hold installations made by package managers, which
+// keep track of their files: Homebrew, Nix, snap and the system's.
+var packageManagerDirs = []string{
+	"/opt/homebrew",
+	"/home/linuxbrew/.linuxbrew",
+	"/usr/local/Cellar",
+	"/usr/local/Homebrew",
+	"/nix/store",
+	"/snap",
+	"/usr/bin",
+	"/usr/lib",
+	"/usr/share",
+}
+
+// managedByPackageManager reports whether path belongs to an installation
+// made by a package manager, including Homebrew kegs under any prefix.
+func managedByPackageManager(path string) bool {
+	path = filepath.ToSlash(path)
+	for _, dir := range packageManagerDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "Cellar" {
+			return true
+		}
+	}
+	return false
+}
+
+// isToolExecutable reports whether name is the tool's binary or one of its
+// companions, with or without the extension of a Windows build.
+func isToolExecutable(tool Tool, name string) bool {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, ".exe"), ".cmd")
+	if base == tool.Binary {
+		return true
+	}
+	for _, companion := range tool.Companions {
+		if base == companion {
+			return true
+		}
+	}
+	return false
+}
+
+// Import adopts an installation of the tool made without pvm, such as one
+// from the Pulumi install script or Homebrew. The tool's executable at path
+// is run with "version" to learn its version; it and the companion binaries
+// next to it that release archives ship (Tool.Companions, e.g.
+// pulumi-language-go) are then copied into a new installed version, and
+// removed from where they were with move. Other files in the directory are
+// left alone, and installations of package managers such as Homebrew cannot
+// be moved. Symlinks are followed. It returns the version.
+func (m *Manager) Import(ctx context.Context, path string, move bool) (string, error) {
+	if m.foreign() {
+		return "", fmt.Errorf("cannot import a %s/%s build on this platform", m.goos, m.arch)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if move && managedByPackageManager(resolved) {
+		return "", fmt.Errorf("%s belongs to a package manager's installation; import it without --move and uninstall it with the package manager", resolved)
+	}
+
+	out, err := exec.CommandContext(ctx, resolved, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run '%s version': %v", path, err)
+	}
+	match := versionOutput.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if match == nil {
+		return "", fmt.Errorf("cannot determine the version of %s from %q", path, strings.TrimSpace(string(out)))
+	}
+	version := match[1]
+
+	versionDir := filepath.Join(m.VersionsDir(), version)
+	if _, err := os.Stat(versionDir); err == nil {
+		return "", fmt.Errorf("%s %s is already installed", m.tool.DisplayName, version)
+	}
+
+	dir := filepath.Dir(resolved)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !isToolExecutable(m.tool, name) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Mode().IsRegular() {
+			names = append(names, name)
+		}
+	}
+
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create version directory: %v", err)
+	}
+	for _, name := range names {
+		if err := copyFile(filepath.Join(dir, name), filepath.Join(versionDir, name)); err != nil {
+			os.RemoveAll(versionDir) // clean up partial import
+			return "", fmt.Errorf("failed to import %s: %v", name, err)
+		}
+	}
+	// Originals are only removed once everything is copied, so a failed
+	// move leaves the installation intact.
+	if move {
+		for _, name := range names {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return version, fmt.Errorf("imported %s %s but failed to remove the original: %v", m.tool.DisplayName, version, err)
+			}
+		}
+	}
+	return version, nil
+}
+
+// copyFile copies src to dst, keeping its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,98 @@
+package pvm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeFakeInstallation creates a Pulumi installation in dir, or a temp dir
+// when it is empty, whose pulumi reports version. It sits next to a companion
+// binary and unrelated files, as in a shared directory like /usr/local/bin.
+func writeFakeInstallation(t *testing.T, dir, version string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executables are shell scripts")
+	}
+	if dir == "" {
+		dir = t.TempDir()
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	files := map[string]string{
+		"pulumi":             "#!/bin/sh\necho v" + version,
+		"pulumi-language-go": "#!/bin/sh",
+		"kubectl":            "#!/bin/sh",
+		"pulumi-backup.sh":   "#!/bin/sh",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestImport(t *testing.T) {
+	m := New(WithRoot(t.TempDir()))
+	ctx := context.Background()
+
+	dir := writeFakeInstallation(t, "", "3.90.0")
+	version, err := m.Import(ctx, filepath.Join(dir, "pulumi"), false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if version != "3.90.0" {
+		t.Errorf("expected version 3.90.0, got %s", version)
+	}
+	for name, want := range map[string]bool{"pulumi": true, "pulumi-language-go": true, "kubectl": false, "pulumi-backup.sh": false} {
+		if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.90.0", name)); (err == nil) != want {
+			t.Errorf("%s imported: %v, want %v", name, err == nil, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pulumi")); err != nil {
+		t.Error("expected a copy to leave the original in place")
+	}
+	if _, err := m.Import(ctx, filepath.Join(dir, "pulumi"), false); err == nil {
+		t.Error("expected an error importing an installed version, got nil")
+	}
+
+	// Moving through a symlink, as Homebrew installs are reached.
+	dir = writeFakeInstallation(t, "", "3.91.1")
+	link := filepath.Join(t.TempDir(), "pulumi")
+	if err := os.Symlink(filepath.Join(dir, "pulumi"), link); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if version, err := m.Import(ctx, link, true); err != nil || version != "3.91.1" {
+		t.Fatalf("Import = %q, %v; want 3.91.1", version, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pulumi")); !os.IsNotExist(err) {
+		t.Error("expected a move to remove the original")
+	}
+	for _, name := range []string{"kubectl", "pulumi-backup.sh"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected the unrelated %s to be left alone", name)
+		}
+	}
+	if versions, _ := m.List(); len(versions) != 2 {
+		t.Errorf("expected two installed versions, got %v", versions)
+	}
+}
+
+func TestImportRefusesToMovePackageManagerInstall(t *testing.T) {
+	m := New(WithRoot(t.TempDir()))
+	ctx := context.Background()
+
+	keg := writeFakeInstallation(t, filepath.Join(t.TempDir(), "Cellar", "pulumi", "3.90.0", "bin"), "3.90.0")
+	if _, err := m.Import(ctx, filepath.Join(keg, "pulumi"), true); err == nil {
+		t.Fatal("expected moving a Homebrew keg to be refused, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(keg, "pulumi")); err != nil {
+		t.Error("expected the keg to be left intact")
+	}
+	if version, err := m.Import(ctx, filepath.Join(keg, "pulumi"), false); err != nil || version != "3.90.0" {
+		t.Errorf("expected a copy of the keg to be allowed, got %q, %v", version, err)
+	}
+}
@@ -0,0 +1,200 @@
+package pvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// Index is a static release index, for distributing releases from any web
+// server or file share:
+//
+//	{
+//	  "releases": [
+//	    {
+//	      "tool": "pulumi",
+//	      "version": "3.78.1",
+//	      "assets": [
+//	        {"os": "linux", "arch": "amd64", "url": "pulumi-v3.78.1-linux-x64.tar.gz", "sha256": "…"}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// Releases without a tool belong to the Pulumi CLI. Relative asset URLs are
+// resolved against the location of the index.
+type Index struct {
+	Releases []IndexRelease `json:"releases"`
+}
+
+// IndexRelease is a release of one tool in an Index. Versions with a
+// pre-release suffix are pre-releases whether or not Prerelease is set.
+type IndexRelease struct {
+	Tool        string       `json:"tool,omitempty"`
+	Version     string       `json:"version"`
+	PublishedAt time.Time    `json:"published_at,omitempty"`
+	Prerelease  bool         `json:"prerelease,omitempty"`
+	NotesURL    string       `json:"notes_url,omitempty"`
+	Assets      []IndexAsset `json:"assets"`
+}
+
+// IndexAsset is the archive of a release for one platform.
+type IndexAsset struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// IndexSource reads releases from a static JSON Index.
+type IndexSource struct {
+	location string
+	client   *http.Client
+}
+
+// NewIndexSource returns an IndexSource reading the index at location, an
+// http(s) URL or a local file path.
+func NewIndexSource(location string, client *http.Client) *IndexSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &IndexSource{location: location, client: client}
+}
+
+func (s *IndexSource) load(ctx context.Context) (*Index, error) {
+	data, err := fetch(ctx, s.client, s.location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release index %s: %w", s.location, err)
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse release index %s: %v", s.location, err)
+	}
+	return &index, nil
+}
+
+// releases returns the releases of tool listed in the index.
+func (s *IndexSource) releases(ctx context.Context, tool Tool) ([]IndexRelease, error) {
+	index, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var releases []IndexRelease
+	for _, release := range index.Releases {
+		name := release.Tool
+		if name == "" {
+			name = Pulumi.Name
+		}
+		if name == tool.Name {
+			releases = append(releases, release)
+		}
+	}
+	return releases, nil
+}
+
+// asset returns the asset of a release for a platform.
+func (s *IndexSource) asset(ctx context.Context, tool Tool, version, goos, arch string) (IndexAsset, error) {
+	releases, err := s.releases(ctx, tool)
+	if err != nil {
+		return IndexAsset{}, err
+	}
+	for _, release := range releases {
+		if strings.TrimPrefix(release.Version, "v") != version {
+			continue
+		}
+		for _, asset := range release.Assets {
+			if asset.OS == goos && asset.Arch == arch {
+				return asset, nil
+			}
+		}
+		return IndexAsset{}, fmt.Errorf("%s %s has no archive for %s-%s in %s", tool.DisplayName, version, goos, arch, s.location)
+	}
+	return IndexAsset{}, fmt.Errorf("%s %s is not listed in %s", tool.DisplayName, version, s.location)
+}
+
+// Describe implements DescribedSource.
+func (s *IndexSource) Describe() (string, string) {
+	return "index", s.location
+}
+
+// Releases implements ReleaseSource. The asset names reported are the ones
+// tool's archives are published under, whatever the index URLs look like.
+func (s *IndexSource) Releases(ctx context.Context, tool Tool) ([]Release, error) {
+	indexReleases, err := s.releases(ctx, tool)
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]Release, len(indexReleases))
+	for i, r := range indexReleases {
+		version := strings.TrimPrefix(r.Version, "v")
+		assets := make([]string, len(r.Assets))
+		for j, asset := range r.Assets {
+			assets[j] = tool.AssetName(version, asset.OS, asset.Arch)
+		}
+		releases[i] = Release{
+			Version:     version,
+			PublishedAt: r.PublishedAt,
+			Prerelease:  r.Prerelease || utils.IsPrerelease(version),
+			NotesURL:    r.NotesURL,
+			Assets:      assets,
+		}
+	}
+	return releases, nil
+}
+
+// Latest implements ReleaseSource.
+func (s *IndexSource) Latest(ctx context.Context, tool Tool) (string, error) {
+	releases, err := s.Releases(ctx, tool)
+	if err != nil {
+		return "", err
+	}
+	return latestStable(releases)
+}
+
+// AssetURL implements ReleaseSource.
+func (s *IndexSource) AssetURL(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	asset, err := s.asset(ctx, tool, version, goos, arch)
+	if err != nil {
+		return "", err
+	}
+	return s.resolve(asset.URL)
+}
+
+// Checksum implements ReleaseSource.
+func (s *IndexSource) Checksum(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	asset, err := s.asset(ctx, tool, version, goos, arch)
+	if err != nil {
+		return "", err
+	}
+	return asset.SHA256, nil
+}
+
+// resolve resolves an asset URL relative to the index location.
+func (s *IndexSource) resolve(ref string) (string, error) {
+	if strings.Contains(ref, "://") {
+		return ref, nil
+	}
+	if strings.HasPrefix(s.location, "http://") || strings.HasPrefix(s.location, "https://") {
+		base, err := url.Parse(s.location)
+		if err != nil {
+			return "", fmt.Errorf("invalid release index URL %s: %v", s.location, err)
+		}
+		rel, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid asset URL %s: %v", ref, err)
+		}
+		return base.ResolveReference(rel).String(), nil
+	}
+	if filepath.IsAbs(ref) {
+		return fileURL(ref), nil
+	}
+	dir := filepath.Dir(strings.TrimPrefix(s.location, "file://"))
+	return fileURL(filepath.Join(dir, ref)), nil
+}
@@ -0,0 +1,110 @@
+package pvm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testIndex = Index{Releases: []IndexRelease{
+	{Version: "3.78.1", Assets: []IndexAsset{
+		{OS: "linux", Arch: "amd64", URL: "pulumi-v3.78.1-linux-x64.tar.gz", SHA256: "abcd"},
+	}},
+	{Version: "3.79.0-alpha.1", Assets: []IndexAsset{
+		{OS: "linux", Arch: "amd64", URL: "https://example.com/pulumi-v3.79.0-alpha.1-linux-x64.tar.gz"},
+	}},
+	{Tool: "esc", Version: "0.9.1", Assets: []IndexAsset{
+		{OS: "linux", Arch: "amd64", URL: "esc-v0.9.1-linux-x64.tar.gz"},
+	}},
+}}
+
+func TestIndexSourceHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/index.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(testIndex)
+	}))
+	defer server.Close()
+
+	source := NewIndexSource(server.URL+"/releases/index.json", server.Client())
+	ctx := context.Background()
+
+	releases, err := source.Releases(ctx, Pulumi)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	versions := releaseVersions(releases)
+	if len(versions) != 2 || versions[0] != "3.78.1" || versions[1] != "3.79.0-alpha.1" {
+		t.Errorf("expected [3.78.1 3.79.0-alpha.1], got %v", versions)
+	}
+
+	latest, err := source.Latest(ctx, Pulumi)
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest != "3.78.1" {
+		t.Errorf("expected the latest stable release 3.78.1, got %s", latest)
+	}
+
+	url, err := source.AssetURL(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("AssetURL: %v", err)
+	}
+	if want := server.URL + "/releases/pulumi-v3.78.1-linux-x64.tar.gz"; url != want {
+		t.Errorf("AssetURL = %s, want %s", url, want)
+	}
+	url, err = source.AssetURL(ctx, Pulumi, "3.79.0-alpha.1", "linux", "amd64")
+	if err != nil || url != "https://example.com/pulumi-v3.79.0-alpha.1-linux-x64.tar.gz" {
+		t.Errorf("expected absolute asset URLs to be kept, got %s, %v", url, err)
+	}
+
+	sum, err := source.Checksum(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil || sum != "abcd" {
+		t.Errorf("Checksum = %q, %v; want abcd", sum, err)
+	}
+
+	if _, err := source.AssetURL(ctx, Pulumi, "3.78.1", "darwin", "arm64"); err == nil {
+		t.Error("expected error for a platform without an asset, got nil")
+	}
+	if _, err := source.AssetURL(ctx, Pulumi, "3.0.0", "linux", "amd64"); err == nil {
+		t.Error("expected error for a version not in the index, got nil")
+	}
+}
+
+func TestIndexSourceFile(t *testing.T) {
+	dir := t.TempDir()
+	data, err := json.Marshal(testIndex)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	indexPath := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	source := NewIndexSource(indexPath, nil)
+	ctx := context.Background()
+
+	releases, err := source.Releases(ctx, ESC)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	versions := releaseVersions(releases)
+	if len(versions) != 1 || versions[0] != "0.9.1" {
+		t.Errorf("expected [0.9.1], got %v", versions)
+	}
+
+	url, err := source.AssetURL(ctx, ESC, "0.9.1", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("AssetURL: %v", err)
+	}
+	if want := fileURL(filepath.Join(dir, "esc-v0.9.1-linux-x64.tar.gz")); url != want {
+		t.Errorf("AssetURL = %s, want %s", url, want)
+	}
+}
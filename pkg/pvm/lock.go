@@ -0,0 +1,214 @@
+package pvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// LockPlatforms are the platforms Lock records when none are given. Those a
+// release has no archive for are left out.
+var LockPlatforms = []string{
+	"darwin/amd64",
+	"darwin/arm64",
+	"linux/amd64",
+	"linux/arm64",
+	"windows/amd64",
+	"windows/arm64",
+}
+
+// LockFile is the content of a pvm.lock file: the exact release archives of
+// each locked tool, so every machine installs the same bytes.
+type LockFile struct {
+	Tools map[string]LockedRelease `json:"tools"`
+}
+
+// LockedRelease is a locked version of a tool and its archives.
+type LockedRelease struct {
+	Version   string           `json:"version"`
+	Artifacts []LockedArtifact `json:"artifacts"`
+}
+
+// LockedArtifact is the archive of a locked release for one platform.
+type LockedArtifact struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// Artifact returns the archive locked for a platform.
+func (r LockedRelease) Artifact(goos, arch string) (LockedArtifact, bool) {
+	for _, artifact := range r.Artifacts {
+		if artifact.OS == goos && artifact.Arch == arch {
+			return artifact, true
+		}
+	}
+	return LockedArtifact{}, false
+}
+
+// releasePlatforms returns the LockPlatforms release has archives of the
+// tool for.
+func (m *Manager) releasePlatforms(release Release) []string {
+	var platforms []string
+	for _, platform := range LockPlatforms {
+		goos, arch, _ := strings.Cut(platform, "/")
+		if release.HasAsset(m.tool.AssetName(release.Version, goos, arch)) {
+			platforms = append(platforms, platform)
+		}
+	}
+	return platforms
+}
+
+// ReadLockFile reads the lock file at path.
+func ReadLockFile(path string) (*LockFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock LockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid lock file %s: %v", path, err)
+	}
+	if lock.Tools == nil {
+		lock.Tools = make(map[string]LockedRelease)
+	}
+	for name, release := range lock.Tools {
+		if err := release.validate(); err != nil {
+			return nil, fmt.Errorf("invalid lock file %s: %s: %v", path, name, err)
+		}
+	}
+	return &lock, nil
+}
+
+// validate checks that the locked version is a full version, so a lock file
+// from a cloned repository cannot name a directory outside the versions
+// directory.
+func (r LockedRelease) validate() error {
+	if !utils.IsExactVersion(r.Version) {
+		return fmt.Errorf("invalid version %q: expected a version such as 3.78.1", r.Version)
+	}
+	return nil
+}
+
+// WriteLockFile writes lock to path with its artifacts in a stable order, so
+// regenerating an unchanged lock file leaves it byte-for-byte identical.
+func WriteLockFile(path string, lock *LockFile) error {
+	for _, release := range lock.Tools {
+		sort.Slice(release.Artifacts, func(i, j int) bool {
+			a, b := release.Artifacts[i], release.Artifacts[j]
+			if a.OS != b.OS {
+				return a.OS < b.OS
+			}
+			return a.Arch < b.Arch
+		})
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Lock resolves version and records the URL and SHA-256 of its archive for
+// each platform, given as "os/arch". Without platforms, the LockPlatforms
+// the release has archives for are recorded. Checksums the release source
+// does not publish are computed by downloading the archive.
+func (m *Manager) Lock(ctx context.Context, version string, platforms []string) (LockedRelease, error) {
+	resolvedVersion, err := m.Resolve(ctx, version)
+	if err != nil {
+		return LockedRelease{}, err
+	}
+
+	if len(platforms) == 0 {
+		releases, err := m.Releases(ctx, false)
+		if err != nil {
+			return LockedRelease{}, fmt.Errorf("failed to fetch releases: %v", err)
+		}
+		release := Release{Version: resolvedVersion}
+		for _, r := range releases {
+			if r.Version == resolvedVersion {
+				release = r
+			}
+		}
+		platforms = m.releasePlatforms(release)
+	}
+
+	locked := LockedRelease{Version: resolvedVersion}
+	for _, platform := range platforms {
+		goos, arch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || arch == "" {
+			return LockedRelease{}, fmt.Errorf("invalid platform %q (expected os/arch, e.g. linux/amd64)", platform)
+		}
+		url, err := m.source.AssetURL(ctx, m.tool, resolvedVersion, goos, arch)
+		if err != nil {
+			return LockedRelease{}, err
+		}
+		checksum, err := m.source.Checksum(ctx, m.tool, resolvedVersion, goos, arch)
+		if err != nil {
+			return LockedRelease{}, err
+		}
+		if checksum == "" {
+			if checksum, err = utils.ArchiveChecksum(ctx, m.client, url); err != nil {
+				return LockedRelease{}, fmt.Errorf("failed to checksum %s: %w", url, err)
+			}
+		}
+		locked.Artifacts = append(locked.Artifacts, LockedArtifact{OS: goos, Arch: arch, URL: url, SHA256: strings.ToLower(checksum)})
+	}
+	if len(locked.Artifacts) == 0 {
+		return LockedRelease{}, fmt.Errorf("%s %s has no archives to lock", m.tool.DisplayName, resolvedVersion)
+	}
+	return locked, nil
+}
+
+// InstallLocked installs exactly the archive locked for the Manager's
+// platform, failing if there is none or its SHA-256 does not match. An
+// already installed version must have been installed from the locked
+// archive, or it fails too.
+func (m *Manager) InstallLocked(ctx context.Context, locked LockedRelease) error {
+	if err := locked.validate(); err != nil {
+		return err
+	}
+	goos, arch := m.goos, m.arch
+	artifact, ok := locked.Artifact(goos, arch)
+	if !ok {
+		return fmt.Errorf("%s %s is not locked for %s/%s; run 'pvm lock --platforms %s/%s' to add it", m.tool.DisplayName, locked.Version, goos, arch, goos, arch)
+	}
+	if artifact.SHA256 == "" {
+		return fmt.Errorf("%s %s has no checksum locked for %s/%s", m.tool.DisplayName, locked.Version, goos, arch)
+	}
+
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), locked.Version)); err == nil {
+		return m.verifyInstalled(locked.Version, artifact.SHA256)
+	}
+	return m.installArchive(ctx, locked.Version, artifact.URL, artifact.SHA256)
+}
+
+// verifyInstalled checks that the installed version came from the archive
+// with the given SHA-256, as recorded when it was installed. Versions
+// installed without a verified checksum, or imported, have no record and
+// fail the check.
+func (m *Manager) verifyInstalled(version, checksum string) error {
+	data, err := os.ReadFile(m.archiveChecksumFile(version))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	recorded := strings.TrimSpace(string(data))
+	if strings.EqualFold(recorded, checksum) {
+		return nil
+	}
+	reason := fmt.Sprintf("it was installed from an archive with SHA-256 %s, not %s", recorded, checksum)
+	if recorded == "" {
+		reason = "it was not installed from a verified archive"
+	}
+	return fmt.Errorf("installed %s %s does not match the lock file: %s; run 'pvm remove %s' and install it again", m.tool.DisplayName, version, reason, m.tool.Spec(version))
+}
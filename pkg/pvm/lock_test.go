@@ -0,0 +1,150 @@
+package pvm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestLockAndInstallLocked(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	locked, err := m.Lock(ctx, "3.78", nil)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if locked.Version != "3.78.1" || len(locked.Artifacts) != len(LockPlatforms) {
+		t.Fatalf("expected 3.78.1 locked for every platform, got %+v", locked)
+	}
+	for _, artifact := range locked.Artifacts {
+		if len(artifact.SHA256) != 64 {
+			t.Errorf("expected a computed SHA-256 for %s/%s, got %q", artifact.OS, artifact.Arch, artifact.SHA256)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), config.LockFile)
+	if err := WriteLockFile(path, &LockFile{Tools: map[string]LockedRelease{Pulumi.Name: locked}}); err != nil {
+		t.Fatalf("WriteLockFile: %v", err)
+	}
+	lock, err := ReadLockFile(path)
+	if err != nil {
+		t.Fatalf("ReadLockFile: %v", err)
+	}
+	locked = lock.Tools[Pulumi.Name]
+
+	if err := m.InstallLocked(ctx, locked); err != nil {
+		t.Fatalf("InstallLocked: %v", err)
+	}
+	if versions, _ := m.List(); len(versions) != 1 || versions[0] != "3.78.1" {
+		t.Errorf("expected [3.78.1] installed, got %v", versions)
+	}
+
+	if err := m.Remove("3.78.1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	for i := range locked.Artifacts {
+		locked.Artifacts[i].SHA256 = strings.Repeat("0", 64)
+	}
+	err = m.InstallLocked(ctx, locked)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.78.1")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be installed when the checksum does not match")
+	}
+}
+
+func TestLockPlatforms(t *testing.T) {
+	m, source := newTestManager(t)
+	source.checksum = "ABCD"
+	ctx := context.Background()
+
+	locked, err := m.Lock(ctx, "3.78.1", []string{"linux/amd64"})
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	want := LockedArtifact{OS: "linux", Arch: "amd64", URL: source.baseURL + "/pulumi-v3.78.1-linux-x64.tar.gz", SHA256: "abcd"}
+	if len(locked.Artifacts) != 1 || locked.Artifacts[0] != want {
+		t.Errorf("Lock = %+v, want [%+v]", locked.Artifacts, want)
+	}
+
+	if _, err := m.Lock(ctx, "3.78.1", []string{"linux"}); err == nil {
+		t.Error("expected an error for a platform without an architecture")
+	}
+
+	if err := m.InstallLocked(ctx, LockedRelease{Version: "3.78.1"}); err == nil || !strings.Contains(err.Error(), "not locked for") {
+		t.Errorf("expected an error for a platform that is not locked, got %v", err)
+	}
+}
+
+func TestInstallLockedVerifiesExistingInstall(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	locked, err := m.Lock(ctx, "3.78.1", []string{m.goos + "/" + m.arch})
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	// A version directory pvm did not verify, e.g. a partial or tampered
+	// install, is not accepted as the locked release.
+	versionDir := filepath.Join(m.VersionsDir(), "3.78.1")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	err = m.InstallLocked(ctx, locked)
+	if err == nil || !strings.Contains(err.Error(), "not installed from a verified archive") {
+		t.Fatalf("expected an unverified install to be rejected, got %v", err)
+	}
+
+	if err := m.Remove("3.78.1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := m.InstallLocked(ctx, locked); err != nil {
+		t.Fatalf("InstallLocked: %v", err)
+	}
+	// Installing the locked release again checks the recorded checksum.
+	if err := m.InstallLocked(ctx, locked); err != nil {
+		t.Errorf("expected the verified install to be accepted, got %v", err)
+	}
+
+	other := locked
+	other.Artifacts = []LockedArtifact{locked.Artifacts[0]}
+	other.Artifacts[0].SHA256 = strings.Repeat("0", 64)
+	err = m.InstallLocked(ctx, other)
+	if err == nil || !strings.Contains(err.Error(), "does not match the lock file") {
+		t.Errorf("expected a different locked checksum to be rejected, got %v", err)
+	}
+
+	if err := m.Remove("3.78.1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(m.archiveChecksumFile("3.78.1")); !os.IsNotExist(err) {
+		t.Error("expected Remove to delete the recorded checksum")
+	}
+}
+
+func TestReadLockFileRejectsPaths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pvm.lock")
+	content := `{"tools": {"pulumi": {"version": "../../../.local/bin", "artifacts": []}}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := ReadLockFile(path); err == nil || !strings.Contains(err.Error(), "invalid version") {
+		t.Errorf("expected a path as the locked version to be rejected, got %v", err)
+	}
+
+	m, _ := newTestManager(t)
+	locked := LockedRelease{Version: "../outside", Artifacts: []LockedArtifact{{OS: m.goos, Arch: m.arch, URL: "http://example.com", SHA256: "00"}}}
+	if err := m.InstallLocked(context.Background(), locked); err == nil {
+		t.Error("expected InstallLocked to reject a path as the version")
+	}
+	if _, err := os.Stat(filepath.Join(m.Root(), "outside")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be written outside the versions directory")
+	}
+}
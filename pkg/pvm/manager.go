@@ -0,0 +1,641 @@
+// Package pvm installs and switches between versions of the Pulumi CLI and
+// related tools such as Pulumi ESC. It is the library behind the pvm command
+// and can be embedded in other programs, for example to make sure a Pulumi
+// version is present before driving it through the Automation API:
+//
+//	m := pvm.New(pvm.WithRoot("/opt/pvm"))
+//	if err := m.Install(ctx, "3.78.1"); err != nil {
+//		return err
+//	}
+//	pulumiPath, err := m.BinaryPath("3.78.1")
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// Tool describes a CLI a Manager can install, such as Pulumi or ESC.
+type Tool = config.Tool
+
+// Release describes a published release of a tool.
+type Release = config.Release
+
+var (
+	// Pulumi is the Pulumi CLI.
+	Pulumi = config.Pulumi
+	// ESC is the Pulumi ESC CLI.
+	ESC = config.ESC
+)
+
+// Manager installs and selects versions of a single tool under a root
+// directory laid out the same way as the pvm command's ~/.pvm.
+type Manager struct {
+	root     string
+	cacheDir string
+	client   *http.Client
+	source   ReleaseSource
+	tool     Tool
+	cacheTTL time.Duration
+	goos     string
+	arch     string
+	warn     func(error)
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithRoot sets the directory versions, symlinks and caches are kept in. It
+// defaults to $PVM_HOME, or ~/.pvm when that is unset.
+func WithRoot(root string) Option {
+	return func(m *Manager) { m.root = root }
+}
+
+// WithCacheDir sets the directory release caches are kept in. It defaults
+// to the root.
+func WithCacheDir(dir string) Option {
+	return func(m *Manager) { m.cacheDir = dir }
+}
+
+// WithHTTPClient sets the client used to download releases and, unless
+// WithReleaseSource is given, to query GitHub.
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *Manager) { m.client = client }
+}
+
+// WithReleaseSource sets where releases are discovered and downloaded from.
+// It defaults to GitHub.
+func WithReleaseSource(source ReleaseSource) Option {
+	return func(m *Manager) { m.source = source }
+}
+
+// WithCacheTTL sets how long the release list is cached before it is fetched
+// again. It defaults to a day; 0 refreshes on every use and CacheTTLNever
+// only when asked to.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(m *Manager) { m.cacheTTL = ttl }
+}
+
+// CacheTTLNever is the cache TTL of a release cache that never expires.
+const CacheTTLNever = config.CacheTTLNever
+
+// WithTool sets the tool the Manager manages. It defaults to Pulumi.
+func WithTool(tool Tool) Option {
+	return func(m *Manager) { m.tool = tool }
+}
+
+// WithPlatform sets the platform builds are installed for. It defaults to
+// the running platform. Builds for other platforms are kept apart under
+// <root>/platforms/<os>-<arch> and cannot be made active.
+func WithPlatform(goos, arch string) Option {
+	return func(m *Manager) { m.goos, m.arch = goos, arch }
+}
+
+// WithWarningHandler sets the function told about failures that do not stop
+// an operation, such as a release cache that could not be saved. They are
+// ignored by default.
+func WithWarningHandler(warn func(error)) Option {
+	return func(m *Manager) { m.warn = warn }
+}
+
+// New returns a Manager configured by opts. It does not read the pvm
+// command's configuration: callers wanting its layout or release source pass
+// them as options.
+func New(opts ...Option) *Manager {
+	m := &Manager{
+		root:     defaultRoot(),
+		client:   http.DefaultClient,
+		tool:     Pulumi,
+		cacheTTL: config.CacheTTL,
+	}
+	m.goos, m.arch = config.GetPlatformInfo()
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.cacheDir == "" {
+		m.cacheDir = m.root
+	}
+	if m.warn == nil {
+		m.warn = func(error) {}
+	}
+	// Symlinks in BinDir must not depend on the working directory.
+	if root, err := filepath.Abs(m.root); err == nil {
+		m.root = root
+	}
+	if cacheDir, err := filepath.Abs(m.cacheDir); err == nil {
+		m.cacheDir = cacheDir
+	}
+	if m.source == nil {
+		m.source = NewGitHubSource(m.client)
+	}
+	return m
+}
+
+// defaultRoot returns $PVM_HOME, or ~/.pvm when that is unset.
+func defaultRoot() string {
+	if root := os.Getenv("PVM_HOME"); root != "" {
+		return root
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, config.PVMDir)
+}
+
+// Tool returns the tool the Manager manages.
+func (m *Manager) Tool() Tool {
+	return m.tool
+}
+
+// Root returns the directory the Manager keeps its state in.
+func (m *Manager) Root() string {
+	return m.root
+}
+
+// CacheDir returns the directory the Manager keeps release caches in.
+func (m *Manager) CacheDir() string {
+	return m.cacheDir
+}
+
+// Platform returns the operating system and architecture the Manager
+// installs builds for.
+func (m *Manager) Platform() (string, string) {
+	return m.goos, m.arch
+}
+
+// foreign reports whether the Manager installs builds for a platform other
+// than the running one.
+func (m *Manager) foreign() bool {
+	goos, arch := config.GetPlatformInfo()
+	return m.goos != goos || m.arch != arch
+}
+
+// VersionsDir returns the directory versions of the tool are installed in.
+func (m *Manager) VersionsDir() string {
+	if m.foreign() {
+		return m.tool.VersionsPath(platformRoot(m.root, m.goos, m.arch))
+	}
+	return m.tool.VersionsPath(m.root)
+}
+
+// platformRoot returns the directory builds for a foreign platform are kept
+// in under root.
+func platformRoot(root, goos, arch string) string {
+	return filepath.Join(root, config.PlatformsDir, goos+"-"+arch)
+}
+
+// BinDir returns the directory holding the symlinks to the active versions.
+// It is the directory to put on PATH.
+func (m *Manager) BinDir() string {
+	return filepath.Join(m.root, config.BinDir)
+}
+
+// loadCache reads the tool's release cache. A cache filled by another
+// release source than the Manager's is treated as missing.
+func (m *Manager) loadCache() (*config.ReleaseCache, error) {
+	path := m.tool.CachePath(m.cacheDir)
+	cache, err := loadCache(path)
+	if err != nil {
+		return nil, err
+	}
+	if !m.ownsCache(cache) {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return cache, nil
+}
+
+// ownsCache reports whether cache was filled by the Manager's release
+// source.
+func (m *Manager) ownsCache(cache *config.ReleaseCache) bool {
+	kind, location := describeSource(m.source)
+	return cache.SourceKind == kind && cache.SourceLocation == location
+}
+
+// Releases returns every release of the tool, including drafts and
+// pre-releases. The list is cached under the root (see WithCacheTTL); refresh
+// bypasses the cache, as does a cache filled by another release source.
+// Sources implementing RefreshingSource only transfer what changed since the
+// cached list was fetched.
+func (m *Manager) Releases(ctx context.Context, refresh bool) ([]Release, error) {
+	cachePath := m.tool.CachePath(m.cacheDir)
+	cache, cacheErr := m.loadCache()
+	if !refresh && cacheErr == nil && time.Since(cache.Timestamp) <= m.cacheTTL {
+		return cache.Releases, nil
+	}
+
+	var releases []Release
+	var pages []ReleasePage
+	var err error
+	if source, ok := m.source.(RefreshingSource); ok {
+		var cached []Release
+		var cachedPages []ReleasePage
+		// Caches without pages predate release metadata; list everything.
+		if cacheErr == nil && len(cache.Pages) > 0 {
+			cached, cachedPages = cache.Releases, cache.Pages
+		}
+		releases, pages, err = source.RefreshReleases(ctx, m.tool, cached, cachedPages)
+	} else {
+		releases, err = m.source.Releases(ctx, m.tool)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kind, location := describeSource(m.source)
+	if err := saveCache(cachePath, releases, pages, kind, location); err != nil {
+		m.warn(fmt.Errorf("failed to save the release cache: %w", err))
+	}
+
+	return releases, nil
+}
+
+// Available returns every released version of the tool other than drafts,
+// using the same cache as Releases.
+func (m *Manager) Available(ctx context.Context, refresh bool) ([]string, error) {
+	releases, err := m.Releases(ctx, refresh)
+	if err != nil {
+		return nil, err
+	}
+	return releaseVersions(releases), nil
+}
+
+// CachedVersions returns the versions in the release cache regardless of its
+// age, without contacting the release source. It is meant for shell
+// completion and other callers that must never block on the network.
+func (m *Manager) CachedVersions() ([]string, error) {
+	cache, err := m.loadCache()
+	if err != nil {
+		return nil, err
+	}
+	return releaseVersions(cache.Releases), nil
+}
+
+// CacheInfo describes a Manager's release cache.
+type CacheInfo struct {
+	Path      string
+	Timestamp time.Time
+	Releases  int
+	TTL       time.Duration
+	// SourceKind and SourceLocation identify the release source the cache
+	// was filled from. They are empty for caches written before sources
+	// were recorded.
+	SourceKind     string
+	SourceLocation string
+	// OtherSource is set when that is not the Manager's release source.
+	OtherSource bool
+}
+
+// Expired reports whether the cache is refreshed the next time it is used.
+func (c CacheInfo) Expired() bool {
+	return c.OtherSource || time.Since(c.Timestamp) > c.TTL
+}
+
+// CacheInfo describes the tool's release cache. The error satisfies
+// os.IsNotExist when there is no cache yet.
+func (m *Manager) CacheInfo() (CacheInfo, error) {
+	path := m.tool.CachePath(m.cacheDir)
+	cache, err := loadCache(path)
+	if err != nil {
+		return CacheInfo{}, err
+	}
+	return CacheInfo{
+		Path:           path,
+		Timestamp:      cache.Timestamp,
+		Releases:       len(cache.Releases),
+		TTL:            m.cacheTTL,
+		SourceKind:     cache.SourceKind,
+		SourceLocation: cache.SourceLocation,
+		OtherSource:    !m.ownsCache(cache),
+	}, nil
+}
+
+// ClearCache deletes the tool's release cache so the next lookup fetches
+// the release list again.
+func (m *Manager) ClearCache() error {
+	if err := os.Remove(m.tool.CachePath(m.cacheDir)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove release cache: %v", err)
+	}
+	return nil
+}
+
+// Resolve turns "latest", an exact version or a version prefix such as
+// "3.78" into a released version.
+func (m *Manager) Resolve(ctx context.Context, version string) (string, error) {
+	if version == "latest" {
+		latest, err := m.source.Latest(ctx, m.tool)
+		if err != nil {
+			return "", fmt.Errorf("failed to get latest version: %w", err)
+		}
+		return latest, nil
+	}
+
+	versions, err := m.Available(ctx, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch versions: %v", err)
+	}
+
+	// Exact match first
+	for _, v := range versions {
+		if v == version {
+			return version, nil
+		}
+	}
+
+	// Fall back to prefix match
+	return utils.FindLatestMatchingVersion(version, versions)
+}
+
+// Install resolves version and downloads it unless it is already installed.
+// If ctx is canceled mid-download, the partially extracted version is
+// removed and the returned error wraps ctx's error.
+func (m *Manager) Install(ctx context.Context, version string) error {
+	resolvedVersion, err := m.Resolve(ctx, version)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), resolvedVersion)); err == nil {
+		return nil
+	}
+
+	downloadURL, err := m.source.AssetURL(ctx, m.tool, resolvedVersion, m.goos, m.arch)
+	if err != nil {
+		return err
+	}
+	checksum, err := m.source.Checksum(ctx, m.tool, resolvedVersion, m.goos, m.arch)
+	if err != nil {
+		return err
+	}
+	return m.installArchive(ctx, resolvedVersion, downloadURL, checksum)
+}
+
+// installArchive downloads the archive of version at url, verifying its
+// checksum when one is given, and extracts it into the version's directory
+// unless the version is already installed. A verified checksum is recorded
+// next to the directory (see archiveChecksumFile). A partial install is
+// removed.
+func (m *Manager) installArchive(ctx context.Context, version, url, checksum string) error {
+	versionDir := filepath.Join(m.VersionsDir(), version)
+	if _, err := os.Stat(versionDir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return fmt.Errorf("failed to create version directory: %v", err)
+	}
+	if err := utils.DownloadAndExtract(ctx, m.client, url, versionDir, m.goos == "windows", 1, checksum); err != nil {
+		os.RemoveAll(versionDir) // clean up partial download
+		return fmt.Errorf("failed to download and extract: %w", err)
+	}
+	if checksum != "" {
+		if err := os.WriteFile(m.archiveChecksumFile(version), []byte(strings.ToLower(checksum)+"\n"), 0644); err != nil {
+			os.RemoveAll(versionDir)
+			return fmt.Errorf("failed to record the archive checksum: %v", err)
+		}
+	}
+	return nil
+}
+
+// archiveChecksumFile returns the file holding the SHA-256 of the archive
+// version was installed from. It sits next to the version's directory
+// rather than in it, where 'pvm use' would link it into the bin directory.
+func (m *Manager) archiveChecksumFile(version string) string {
+	return filepath.Join(m.VersionsDir(), version+".sha256")
+}
+
+// List returns the installed versions of the tool, newest first.
+func (m *Manager) List() ([]string, error) {
+	files, err := os.ReadDir(m.VersionsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []string
+	for _, file := range files {
+		if file.IsDir() {
+			versions = append(versions, file.Name())
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return utils.SemverGreater(versions[i], versions[j])
+	})
+	return versions, nil
+}
+
+// Installation is an installed version and the platform it was built for.
+type Installation struct {
+	Version string
+	OS      string
+	Arch    string
+}
+
+// Installations returns the installed versions of the tool for every
+// platform: those of the running platform first, then the builds installed
+// for other platforms with WithPlatform, each newest first.
+func (m *Manager) Installations() ([]Installation, error) {
+	goos, arch := config.GetPlatformInfo()
+	platforms := [][2]string{{goos, arch}}
+
+	dirs, err := os.ReadDir(filepath.Join(m.root, config.PlatformsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		platformOS, platformArch, ok := strings.Cut(dir.Name(), "-")
+		if dir.IsDir() && ok && (platformOS != goos || platformArch != arch) {
+			platforms = append(platforms, [2]string{platformOS, platformArch})
+		}
+	}
+
+	var installations []Installation
+	for _, platform := range platforms {
+		pm := *m
+		pm.goos, pm.arch = platform[0], platform[1]
+		versions, err := pm.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			installations = append(installations, Installation{Version: version, OS: pm.goos, Arch: pm.arch})
+		}
+	}
+	return installations, nil
+}
+
+// Current returns the active version of the tool, or an empty string when
+// none has been selected.
+func (m *Manager) Current() (string, error) {
+	linkTarget, err := os.Readlink(filepath.Join(m.BinDir(), m.tool.Binary))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// Extract version from the symlink path: <versions>/<version>/<binary>
+	rel, err := filepath.Rel(m.VersionsDir(), linkTarget)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", nil
+	}
+	return strings.Split(rel, string(filepath.Separator))[0], nil
+}
+
+// ResolveInstalled resolves a version or prefix against the installed
+// versions only, without consulting the release source.
+func (m *Manager) ResolveInstalled(version string) (string, error) {
+	installed, err := m.List()
+	if err != nil {
+		return "", err
+	}
+	for _, v := range installed {
+		if v == version {
+			return version, nil
+		}
+	}
+
+	resolved, err := utils.FindLatestMatchingVersion(version, installed)
+	if err != nil {
+		return "", fmt.Errorf("version %s is not installed", version)
+	}
+	return resolved, nil
+}
+
+// Use makes an installed version the active one by linking its binaries
+// into BinDir. Symlinks belonging to other tools are left alone.
+func (m *Manager) Use(version string) error {
+	if m.foreign() {
+		return fmt.Errorf("cannot use a %s/%s build on this platform", m.goos, m.arch)
+	}
+	resolvedVersion, err := m.ResolveInstalled(version)
+	if err != nil {
+		return err
+	}
+	versionDir := filepath.Join(m.VersionsDir(), resolvedVersion)
+
+	binPath := m.BinDir()
+	if err := os.MkdirAll(binPath, 0755); err != nil {
+		return fmt.Errorf("failed to create bin directory: %v", err)
+	}
+
+	binFiles, err := os.ReadDir(versionDir)
+	if err != nil {
+		return fmt.Errorf("failed to read version directory: %v", err)
+	}
+	provided := make(map[string]bool, len(binFiles))
+	for _, file := range binFiles {
+		provided[file.Name()] = true
+	}
+
+	// Remove existing symlinks into this tool's versions, and any other
+	// symlink the new version would collide with. Other tools keep theirs.
+	files, err := os.ReadDir(binPath)
+	if err != nil {
+		return fmt.Errorf("failed to read bin directory: %v", err)
+	}
+
+	toolPrefix := filepath.Clean(m.VersionsDir()) + string(filepath.Separator)
+	for _, file := range files {
+		filePath := filepath.Join(binPath, file.Name())
+		target, err := os.Readlink(filePath)
+		if err != nil {
+			continue
+		}
+		if provided[file.Name()] || strings.HasPrefix(filepath.Clean(target), toolPrefix) {
+			if err := os.Remove(filePath); err != nil {
+				return fmt.Errorf("failed to remove existing symlink %s: %v", file.Name(), err)
+			}
+		}
+	}
+
+	// Create new symlinks for every file in the version directory
+	for _, file := range binFiles {
+		if !file.IsDir() {
+			sourcePath := filepath.Join(versionDir, file.Name())
+			symlinkPath := filepath.Join(binPath, file.Name())
+
+			if err := os.Symlink(sourcePath, symlinkPath); err != nil {
+				return fmt.Errorf("failed to create symlink for %s: %v", file.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Remove deletes an installed version. The active version cannot be removed.
+func (m *Manager) Remove(version string) error {
+	current, err := m.Current()
+	if err != nil {
+		return fmt.Errorf("failed to check current version: %w", err)
+	}
+	if current == version {
+		return fmt.Errorf("cannot remove version %s: currently in use", version)
+	}
+
+	versionDir := filepath.Join(m.VersionsDir(), version)
+	if _, err := os.Stat(versionDir); os.IsNotExist(err) {
+		return fmt.Errorf("version %s is not installed", version)
+	}
+
+	if err := os.RemoveAll(versionDir); err != nil {
+		return fmt.Errorf("failed to remove version %s: %w", version, err)
+	}
+	if err := os.Remove(m.archiveChecksumFile(version)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove version %s: %w", version, err)
+	}
+
+	return nil
+}
+
+// VersionDir returns the absolute install directory of an installed version.
+func (m *Manager) VersionDir(version string) (string, error) {
+	versionDir, err := filepath.Abs(filepath.Join(m.VersionsDir(), version))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(versionDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("version %s is not installed", version)
+	}
+	return versionDir, nil
+}
+
+// BinaryPath returns the absolute path of the tool's main executable in an
+// installed version.
+func (m *Manager) BinaryPath(version string) (string, error) {
+	return m.ExecutablePath(version, m.tool.Binary)
+}
+
+// ExecutablePath returns the absolute path of a binary shipped with an
+// installed version, e.g. "pulumi-language-python".
+func (m *Manager) ExecutablePath(version, name string) (string, error) {
+	versionDir, err := m.VersionDir(version)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := []string{name}
+	if m.goos == "windows" && filepath.Ext(name) == "" {
+		candidates = append(candidates, name+".exe")
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(versionDir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("binary %s not found in %s %s", name, m.tool.DisplayName, version)
+}
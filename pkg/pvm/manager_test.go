@@ -0,0 +1,623 @@
+package pvm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+// fakeSource serves a fixed release list and points asset URLs at baseURL.
+type fakeSource struct {
+	versions []string
+	latest   string
+	baseURL  string
+	checksum string
+	calls    int
+}
+
+func (s *fakeSource) Describe() (string, string) {
+	return "fake", ""
+}
+
+func (s *fakeSource) Releases(ctx context.Context, tool Tool) ([]Release, error) {
+	s.calls++
+	releases := make([]Release, len(s.versions))
+	for i, v := range s.versions {
+		releases[i] = Release{Version: v}
+	}
+	return releases, nil
+}
+
+func (s *fakeSource) Latest(ctx context.Context, tool Tool) (string, error) {
+	return s.latest, nil
+}
+
+func (s *fakeSource) AssetURL(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	return s.baseURL + "/" + tool.AssetName(version, goos, arch), nil
+}
+
+func (s *fakeSource) Checksum(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	return s.checksum, nil
+}
+
+// buildTarGz creates a tar.gz archive with files under a top-level directory,
+// the way Pulumi CLI releases are packaged.
+func buildTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: "pulumi/" + name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("tar write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("tar write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newTestManager returns a Manager rooted in a temp dir whose releases are
+// served by an httptest server.
+func newTestManager(t *testing.T, opts ...Option) (*Manager, *fakeSource) {
+	t.Helper()
+	archive := buildTarGz(t, map[string]string{"pulumi": "#!/bin/sh", "pulumi-language-go": "#!/bin/sh"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+
+	source := &fakeSource{versions: []string{"3.78.1", "3.78.0", "3.77.5"}, latest: "3.78.1", baseURL: server.URL}
+	opts = append([]Option{WithRoot(t.TempDir()), WithReleaseSource(source)}, opts...)
+	return New(opts...), source
+}
+
+// installFake creates an installed version containing binaries.
+func installFake(t *testing.T, m *Manager, version string, binaries ...string) {
+	t.Helper()
+	dir := filepath.Join(m.VersionsDir(), version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	for _, name := range binaries {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh"), 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PVM_HOME", tmpDir)
+
+	m := New()
+	if m.Root() != tmpDir {
+		t.Errorf("Root() = %s, want %s", m.Root(), tmpDir)
+	}
+	if m.CacheDir() != tmpDir {
+		t.Errorf("CacheDir() = %s, want %s", m.CacheDir(), tmpDir)
+	}
+	if m.Tool().Name != Pulumi.Name {
+		t.Errorf("Tool() = %s, want pulumi", m.Tool().Name)
+	}
+	if _, ok := m.source.(*GitHubSource); !ok {
+		t.Errorf("expected the GitHub release source by default, got %T", m.source)
+	}
+}
+
+func TestInstallAndUse(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.Install(ctx, "3.78"); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	versions, err := m.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(versions) != 1 || versions[0] != "3.78.1" {
+		t.Fatalf("expected [3.78.1] installed, got %v", versions)
+	}
+
+	if err := m.Use("3.78.1"); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	current, err := m.Current()
+	if err != nil {
+		t.Fatalf("Current: %v", err)
+	}
+	if current != "3.78.1" {
+		t.Errorf("expected current version 3.78.1, got %q", current)
+	}
+
+	path, err := m.BinaryPath("3.78.1")
+	if err != nil {
+		t.Fatalf("BinaryPath: %v", err)
+	}
+	if want := filepath.Join(m.VersionsDir(), "3.78.1", "pulumi"); path != want {
+		t.Errorf("BinaryPath = %s, want %s", path, want)
+	}
+	target, err := os.Readlink(filepath.Join(m.BinDir(), "pulumi-language-go"))
+	if err != nil {
+		t.Fatalf("readlink: %v", err)
+	}
+	if want := filepath.Join(m.VersionsDir(), "3.78.1", "pulumi-language-go"); target != want {
+		t.Errorf("symlink target = %s, want %s", target, want)
+	}
+}
+
+func TestInstallCleansUpFailedDownload(t *testing.T) {
+	m, source := newTestManager(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	source.baseURL = server.URL
+
+	if err := m.Install(context.Background(), "3.78.1"); err == nil {
+		t.Fatal("expected error for a failed download, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.78.1")); !os.IsNotExist(err) {
+		t.Error("expected the partial version directory to be removed")
+	}
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	m, source := newTestManager(t)
+	source.checksum = strings.Repeat("0", 64)
+
+	err := m.Install(context.Background(), "3.78.1")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.78.1")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be installed when the checksum does not match")
+	}
+}
+
+func TestInstallCanceled(t *testing.T) {
+	m, source := newTestManager(t)
+	archive := buildTarGz(t, map[string]string{"pulumi": "#!/bin/sh"})
+
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send part of the archive, then stall until the client gives up.
+		_, _ = w.Write(archive[:len(archive)/2])
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	source.baseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	err := m.Install(ctx, "3.78.1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.78.1")); !os.IsNotExist(err) {
+		t.Error("expected the partial version directory to be removed")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m, source := newTestManager(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"3.78.0", "3.78.0", false},
+		{"3.78", "3.78.1", false},
+		{"3.77", "3.77.5", false},
+		{"latest", "3.78.1", false},
+		{"3.79", "", true},
+	}
+	for _, tc := range tests {
+		got, err := m.Resolve(ctx, tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Resolve(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+
+	if source.calls != 1 {
+		t.Errorf("expected the release list to be fetched once and cached, got %d fetches", source.calls)
+	}
+}
+
+func TestAvailableRefresh(t *testing.T) {
+	m, source := newTestManager(t)
+	ctx := context.Background()
+
+	if _, err := m.Available(ctx, false); err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+	source.versions = append([]string{"3.79.0"}, source.versions...)
+	versions, err := m.Available(ctx, true)
+	if err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+	if source.calls != 2 || versions[0] != "3.79.0" {
+		t.Errorf("expected refresh to bypass the cache, got %v after %d fetches", versions, source.calls)
+	}
+}
+
+func TestCachedVersionsIgnoresTTL(t *testing.T) {
+	m, source := newTestManager(t)
+
+	if _, err := m.CachedVersions(); err == nil {
+		t.Error("expected error without a cache file, got nil")
+	}
+
+	data, _ := json.Marshal(config.ReleaseCache{
+		Versions:   []string{"3.70.0"},
+		Timestamp:  time.Now().Add(-2 * config.CacheTTL),
+		SourceKind: "fake",
+	})
+	if err := os.WriteFile(m.Tool().CachePath(m.CacheDir()), data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	versions, err := m.CachedVersions()
+	if err != nil {
+		t.Fatalf("CachedVersions: %v", err)
+	}
+	if len(versions) != 1 || versions[0] != "3.70.0" {
+		t.Errorf("expected [3.70.0], got %v", versions)
+	}
+	if source.calls != 0 {
+		t.Error("expected CachedVersions not to contact the release source")
+	}
+
+	if _, err := m.Available(context.Background(), false); err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+	if source.calls != 1 {
+		t.Error("expected Available to refetch the expired cache")
+	}
+}
+
+func TestReleasesCached(t *testing.T) {
+	m, source := newTestManager(t)
+	ctx := context.Background()
+	source.versions = []string{"3.79.0-alpha.1", "3.78.1"}
+
+	if _, err := m.Releases(ctx, false); err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	releases, err := m.Releases(ctx, false)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	if source.calls != 1 {
+		t.Errorf("expected releases to be served from the cache, got %d fetches", source.calls)
+	}
+	if len(releases) != 2 || releases[0].Version != "3.79.0-alpha.1" {
+		t.Errorf("expected releases sorted newest first, got %v", releases)
+	}
+}
+
+func TestReleasesReportsUnsavedCache(t *testing.T) {
+	var warnings []error
+	cacheDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(cacheDir, nil, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	m, _ := newTestManager(t, WithCacheDir(cacheDir), WithWarningHandler(func(err error) {
+		warnings = append(warnings, err)
+	}))
+
+	releases, err := m.Releases(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	if len(releases) != 3 {
+		t.Errorf("expected the releases despite the unsaved cache, got %v", releases)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "failed to save the release cache") {
+		t.Errorf("expected a warning about the unsaved cache, got %v", warnings)
+	}
+}
+
+func TestLoadCacheWithoutReleases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "releases.cache")
+	data, _ := json.Marshal(config.ReleaseCache{Versions: []string{"3.78.1", "3.79.0-alpha.1"}, Timestamp: time.Now()})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	cache, err := loadCache(path)
+	if err != nil {
+		t.Fatalf("loadCache: %v", err)
+	}
+	if len(cache.Releases) != 2 || cache.Releases[0].Version != "3.78.1" || !cache.Releases[1].Prerelease {
+		t.Errorf("expected releases built from the cached versions, got %+v", cache.Releases)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	ctx := context.Background()
+
+	m, source := newTestManager(t, WithCacheTTL(0))
+	for i := 0; i < 2; i++ {
+		if _, err := m.Available(ctx, false); err != nil {
+			t.Fatalf("Available: %v", err)
+		}
+	}
+	if source.calls != 2 {
+		t.Errorf("expected a TTL of 0 to refresh on every use, got %d fetches", source.calls)
+	}
+
+	m, source = newTestManager(t, WithCacheTTL(CacheTTLNever))
+	data, _ := json.Marshal(config.ReleaseCache{Versions: []string{"3.70.0"}, Timestamp: time.Now().AddDate(-1, 0, 0), SourceKind: "fake"})
+	if err := os.WriteFile(m.Tool().CachePath(m.CacheDir()), data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := m.Available(ctx, false); err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+	if source.calls != 0 {
+		t.Error("expected a cache that never expires to be used however old it is")
+	}
+}
+
+func TestCacheInfoAndClear(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	if _, err := m.CacheInfo(); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error without a cache, got %v", err)
+	}
+	if _, err := m.Available(context.Background(), false); err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+
+	info, err := m.CacheInfo()
+	if err != nil {
+		t.Fatalf("CacheInfo: %v", err)
+	}
+	if info.Releases != 3 || info.Path != m.Tool().CachePath(m.CacheDir()) || info.Expired() {
+		t.Errorf("unexpected cache info %+v", info)
+	}
+
+	if err := m.ClearCache(); err != nil {
+		t.Fatalf("ClearCache: %v", err)
+	}
+	if _, err := m.CacheInfo(); !os.IsNotExist(err) {
+		t.Errorf("expected the cache to be removed, got %v", err)
+	}
+	if err := m.ClearCache(); err != nil {
+		t.Errorf("expected clearing a missing cache to succeed, got %v", err)
+	}
+}
+
+func TestCurrentNoVersion(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	version, err := m.Current()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "" {
+		t.Errorf("expected empty version, got: %s", version)
+	}
+}
+
+func TestUseKeepsOtherTools(t *testing.T) {
+	m, _ := newTestManager(t)
+	esc := New(WithRoot(m.Root()), WithTool(ESC))
+	installFake(t, m, "3.78.1", "pulumi")
+	installFake(t, esc, "0.9.1", "esc")
+
+	if err := esc.Use("0.9.1"); err != nil {
+		t.Fatalf("Use(esc): %v", err)
+	}
+	if err := m.Use("3.78.1"); err != nil {
+		t.Fatalf("Use(pulumi): %v", err)
+	}
+
+	if v, _ := esc.Current(); v != "0.9.1" {
+		t.Errorf("expected esc 0.9.1 to stay selected, got %q", v)
+	}
+	if v, _ := m.Current(); v != "3.78.1" {
+		t.Errorf("expected pulumi 3.78.1, got %q", v)
+	}
+	if versions, _ := esc.List(); len(versions) != 1 || versions[0] != "0.9.1" {
+		t.Errorf("unexpected esc versions: %v", versions)
+	}
+}
+
+func TestUseNotInstalled(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	if err := m.Use("3.78.1"); err == nil {
+		t.Error("expected error for non-installed version, got nil")
+	}
+}
+
+func TestResolveInstalled(t *testing.T) {
+	m, _ := newTestManager(t)
+	for _, v := range []string{"3.78.1", "3.78.0", "3.77.5"} {
+		installFake(t, m, v)
+	}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"3.78.0", "3.78.0", false},
+		{"3.78", "3.78.1", false},
+		{"3", "3.78.1", false},
+		{"3.79", "", true},
+	}
+	for _, tc := range tests {
+		got, err := m.ResolveInstalled(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ResolveInstalled(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ResolveInstalled(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	m, _ := newTestManager(t)
+	installFake(t, m, "3.78.1", "pulumi")
+	installFake(t, m, "3.78.0", "pulumi")
+	if err := m.Use("3.78.1"); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+
+	if err := m.Remove("3.78.0"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.78.0")); !os.IsNotExist(err) {
+		t.Error("expected 3.78.0 directory to be gone")
+	}
+	if err := m.Remove("3.78.1"); err == nil {
+		t.Error("expected error when removing the active version, got nil")
+	}
+	if err := m.Remove("9.9.9"); err == nil {
+		t.Error("expected error for non-installed version, got nil")
+	}
+}
+
+func TestExecutablePath(t *testing.T) {
+	m, _ := newTestManager(t)
+	installFake(t, m, "3.78.1", "pulumi", "pulumi-language-go")
+
+	path, err := m.ExecutablePath("3.78.1", "pulumi-language-go")
+	if err != nil {
+		t.Fatalf("ExecutablePath: %v", err)
+	}
+	if want := filepath.Join(m.VersionsDir(), "3.78.1", "pulumi-language-go"); path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+
+	if _, err := m.ExecutablePath("3.78.1", "pulumi-language-java"); err == nil {
+		t.Error("expected error for missing binary, got nil")
+	}
+	if _, err := m.BinaryPath("9.9.9"); err == nil {
+		t.Error("expected error for non-installed version, got nil")
+	}
+}
+
+func TestInstallForeignPlatform(t *testing.T) {
+	goos, arch := "darwin", "arm64"
+	if runtime.GOOS == goos && runtime.GOARCH == arch {
+		goos = "linux"
+	}
+	root := t.TempDir()
+	m, _ := newTestManager(t, WithRoot(root), WithPlatform(goos, arch))
+
+	if err := m.Install(context.Background(), "3.78.1"); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	want := filepath.Join(root, config.PlatformsDir, goos+"-"+arch, config.VersionsDir, "3.78.1")
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected the build in %s: %v", want, err)
+	}
+	if err := m.Use("3.78.1"); err == nil {
+		t.Error("expected an error when using a build for another platform")
+	}
+
+	native := New(WithRoot(root))
+	installDir := filepath.Join(native.VersionsDir(), "3.77.5")
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if versions, _ := native.List(); len(versions) != 1 || versions[0] != "3.77.5" {
+		t.Errorf("expected foreign builds to be left out of List, got %v", versions)
+	}
+
+	installations, err := native.Installations()
+	if err != nil {
+		t.Fatalf("Installations: %v", err)
+	}
+	wantInstallations := []Installation{
+		{Version: "3.77.5", OS: runtime.GOOS, Arch: runtime.GOARCH},
+		{Version: "3.78.1", OS: goos, Arch: arch},
+	}
+	if len(installations) != 2 || installations[0] != wantInstallations[0] || installations[1] != wantInstallations[1] {
+		t.Errorf("Installations = %+v, want %+v", installations, wantInstallations)
+	}
+}
+
+// renamedSource is a fakeSource describing itself as another source.
+type renamedSource struct {
+	*fakeSource
+	location string
+}
+
+func (s renamedSource) Describe() (string, string) {
+	return "mirror", s.location
+}
+
+func TestReleasesCacheFromOtherSource(t *testing.T) {
+	ctx := context.Background()
+	m, source := newTestManager(t)
+	if _, err := m.Releases(ctx, false); err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+
+	mirror := renamedSource{&fakeSource{versions: []string{"3.78.1"}, latest: "3.78.1"}, "http://mirror.example.com"}
+	other := New(WithRoot(m.Root()), WithReleaseSource(mirror))
+	info, err := other.CacheInfo()
+	if err != nil {
+		t.Fatalf("CacheInfo: %v", err)
+	}
+	if info.SourceKind != "fake" || !info.OtherSource || !info.Expired() {
+		t.Errorf("expected the cache to be reported as filled by the other source, got %+v", info)
+	}
+	if _, err := other.CachedVersions(); !os.IsNotExist(err) {
+		t.Errorf("expected the other source's cache not to be used for completion, got %v", err)
+	}
+
+	versions, err := other.Available(ctx, false)
+	if err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+	if mirror.calls != 1 || len(versions) != 1 {
+		t.Errorf("expected the mirror to be asked for its own releases, got %v after %d fetches", versions, mirror.calls)
+	}
+	if info, _ := other.CacheInfo(); info.SourceKind != "mirror" || info.SourceLocation != "http://mirror.example.com" || info.OtherSource {
+		t.Errorf("expected the cache to be refilled by the mirror, got %+v", info)
+	}
+	if _, err := m.Releases(ctx, false); err != nil || source.calls != 2 {
+		t.Errorf("expected switching back to refetch, got %d fetches, %v", source.calls, err)
+	}
+}
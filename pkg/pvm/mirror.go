@@ -0,0 +1,289 @@
+package pvm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// SyncMirror downloads the stable releases of the tool matching constraint
+// (see utils.MatchesConstraint, e.g. ">=3.80") into the mirror directory dir,
+// which is laid out like a bundle (see AddToBundle). Without platforms, the
+// LockPlatforms each release has archives for are mirrored. Archives already
+// in dir are not downloaded again. It returns the versions it downloaded
+// archives of, newest first.
+func (m *Manager) SyncMirror(ctx context.Context, dir, constraint string, platforms []string) ([]string, error) {
+	releases, err := m.Releases(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mirror directory: %v", err)
+	}
+	sums, err := readBundleChecksums(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return utils.SemverGreater(releases[i].Version, releases[j].Version)
+	})
+
+	matched := 0
+	var synced []string
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		ok, err := utils.MatchesConstraint(release.Version, constraint)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		matched++
+
+		wanted := platforms
+		if len(wanted) == 0 {
+			wanted = m.releasePlatforms(release)
+		}
+		var missing []string
+		for _, platform := range wanted {
+			goos, arch, _ := strings.Cut(platform, "/")
+			asset := m.tool.AssetName(release.Version, goos, arch)
+			if _, err := os.Stat(filepath.Join(dir, asset)); err != nil || sums[asset] == "" {
+				missing = append(missing, platform)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		if _, err := m.AddToBundle(ctx, dir, release.Version, missing); err != nil {
+			return synced, fmt.Errorf("failed to mirror %s %s: %w", m.tool.DisplayName, release.Version, err)
+		}
+		synced = append(synced, release.Version)
+	}
+	if matched == 0 {
+		return nil, fmt.Errorf("no %s release matches %q", m.tool.DisplayName, constraint)
+	}
+	return synced, nil
+}
+
+// NewMirrorHandler returns an HTTP handler serving the releases in dir, a
+// directory filled by SyncMirror or holding archives under their original
+// names, in the layout of the GitHub API and release downloads:
+//
+//	/repos/<owner>/<repo>/releases                         release list
+//	/repos/<owner>/<repo>/releases/latest                  latest release
+//	/<owner>/<repo>/releases/download/v<version>/<asset>   archives and checksums
+//
+// Point pvm at it with the "mirror" release source.
+func NewMirrorHandler(dir string) http.Handler {
+	return &mirrorHandler{dir: dir}
+}
+
+type mirrorHandler struct {
+	dir string
+}
+
+// mirrorRelease is a release as listed by the GitHub API.
+type mirrorRelease struct {
+	TagName     string        `json:"tag_name"`
+	PublishedAt *time.Time    `json:"published_at,omitempty"`
+	Prerelease  bool          `json:"prerelease"`
+	Draft       bool          `json:"draft"`
+	HTMLURL     string        `json:"html_url,omitempty"`
+	Assets      []mirrorAsset `json:"assets"`
+}
+
+type mirrorAsset struct {
+	Name string `json:"name"`
+}
+
+func (h *mirrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "releases":
+		h.serveReleases(w, r, parts[1]+"/"+parts[2])
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "releases" && parts[4] == "latest":
+		h.serveLatest(w, r, parts[1]+"/"+parts[2])
+	case len(parts) == 6 && parts[2] == "releases" && parts[3] == "download" && strings.HasPrefix(parts[4], "v"):
+		h.serveAsset(w, r, parts[0]+"/"+parts[1], strings.TrimPrefix(parts[4], "v"), parts[5])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// releases returns the mirrored releases of the tool published in repo,
+// newest first.
+func (h *mirrorHandler) releases(ctx context.Context, repo string) (Tool, []Release, bool) {
+	for _, tool := range config.Tools {
+		if tool.Repo != repo {
+			continue
+		}
+		var releases []Release
+		if cache, err := loadCache(tool.CachePath(h.dir)); err == nil {
+			releases = cache.Releases
+		} else if releases, err = NewDirSource(h.dir).Releases(ctx, tool); err != nil {
+			return tool, nil, true
+		}
+		sort.Slice(releases, func(i, j int) bool {
+			return utils.SemverGreater(releases[i].Version, releases[j].Version)
+		})
+		return tool, releases, true
+	}
+	return Tool{}, nil, false
+}
+
+func (h *mirrorHandler) serveReleases(w http.ResponseWriter, r *http.Request, repo string) {
+	_, releases, ok := h.releases(r.Context(), repo)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage <= 0 || perPage > 100 {
+		perPage = 30
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+	start := min((page-1)*perPage, len(releases))
+	end := min(start+perPage, len(releases))
+
+	list := make([]mirrorRelease, 0, end-start)
+	for _, release := range releases[start:end] {
+		list = append(list, toMirrorRelease(release))
+	}
+	if end < len(releases) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		query.Set("per_page", strconv.Itoa(perPage))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+	writeMirrorJSON(w, r, list)
+}
+
+func (h *mirrorHandler) serveLatest(w http.ResponseWriter, r *http.Request, repo string) {
+	_, releases, ok := h.releases(r.Context(), repo)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	latest, err := latestStable(releases)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	for _, release := range releases {
+		if release.Version == latest {
+			writeMirrorJSON(w, r, toMirrorRelease(release))
+			return
+		}
+	}
+}
+
+func (h *mirrorHandler) serveAsset(w http.ResponseWriter, r *http.Request, repo, version, name string) {
+	tool, _, ok := h.releases(r.Context(), repo)
+	if !ok || name != filepath.Base(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := filepath.Join(h.dir, name)
+	match := assetPattern(tool).FindStringSubmatch(name)
+	isChecksums := name == tool.ChecksumsName(version) && name != ""
+	if (match == nil || match[1] != version) && !isChecksums {
+		http.NotFound(w, r)
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		http.ServeFile(w, r, path)
+		return
+	}
+	if !isChecksums {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Synced mirrors keep one SHA256SUMS; serve the release's part of it.
+	sums, err := readBundleChecksums(h.dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var lines []string
+	for asset, sum := range sums {
+		if match := assetPattern(tool).FindStringSubmatch(asset); match != nil && match[1] == version {
+			lines = append(lines, fmt.Sprintf("%s  %s\n", sum, asset))
+		}
+	}
+	if len(lines) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	sort.Strings(lines)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(strings.Join(lines, "")))
+}
+
+// toMirrorRelease converts release to its GitHub API form.
+func toMirrorRelease(release Release) mirrorRelease {
+	mr := mirrorRelease{
+		TagName:    "v" + release.Version,
+		Prerelease: release.Prerelease,
+		Draft:      release.Draft,
+		HTMLURL:    release.NotesURL,
+		Assets:     []mirrorAsset{},
+	}
+	if !release.PublishedAt.IsZero() {
+		published := release.PublishedAt
+		mr.PublishedAt = &published
+	}
+	for _, asset := range release.Assets {
+		mr.Assets = append(mr.Assets, mirrorAsset{Name: asset})
+	}
+	return mr
+}
+
+// writeMirrorJSON writes v as JSON with an ETag, answering a matching
+// If-None-Match with 304 Not Modified.
+func writeMirrorJSON(w http.ResponseWriter, r *http.Request, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,93 @@
+package pvm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncMirror(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	synced, err := m.SyncMirror(ctx, dir, ">=3.78", []string{"linux/amd64"})
+	if err != nil {
+		t.Fatalf("SyncMirror: %v", err)
+	}
+	if len(synced) != 2 || synced[0] != "3.78.1" || synced[1] != "3.78.0" {
+		t.Errorf("SyncMirror = %v, want [3.78.1 3.78.0]", synced)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pulumi-v3.78.0-linux-x64.tar.gz")); err != nil {
+		t.Errorf("expected the archive in the mirror: %v", err)
+	}
+
+	synced, err = m.SyncMirror(ctx, dir, ">=3.78", []string{"linux/amd64"})
+	if err != nil || len(synced) != 0 {
+		t.Errorf("expected nothing to sync the second time, got %v, %v", synced, err)
+	}
+	if _, err := m.SyncMirror(ctx, dir, ">=4", nil); err == nil {
+		t.Error("expected an error when no release matches")
+	}
+}
+
+func TestMirrorHandler(t *testing.T) {
+	upstream, _ := newTestManager(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	if _, err := upstream.SyncMirror(ctx, dir, ">=3.78", []string{"linux/amd64"}); err != nil {
+		t.Fatalf("SyncMirror: %v", err)
+	}
+
+	server := httptest.NewServer(NewMirrorHandler(dir))
+	defer server.Close()
+	m := New(WithRoot(t.TempDir()), WithReleaseSource(NewMirrorSource(server.URL, nil)), WithPlatform("linux", "amd64"))
+
+	versions, err := m.Available(ctx, true)
+	if err != nil {
+		t.Fatalf("Available: %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "3.78.1" || versions[1] != "3.78.0" {
+		t.Errorf("Available = %v, want [3.78.1 3.78.0]", versions)
+	}
+	if latest, err := m.Resolve(ctx, "latest"); err != nil || latest != "3.78.1" {
+		t.Errorf("Resolve(latest) = %s, %v; want 3.78.1", latest, err)
+	}
+
+	if sum, err := m.source.Checksum(ctx, Pulumi, "3.78.0", "linux", "amd64"); err != nil || len(sum) != 64 {
+		t.Errorf("expected the mirror to serve checksums, got %q, %v", sum, err)
+	}
+	if err := m.Install(ctx, "3.78.0"); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.VersionsDir(), "3.78.0", "pulumi")); err != nil {
+		t.Errorf("expected 3.78.0 to be installed from the mirror: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/repos/pulumi/pulumi/releases?per_page=1")
+	if err != nil {
+		t.Fatalf("GET releases: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("Link") == "" || resp.Header.Get("ETag") == "" {
+		t.Errorf("expected pagination and ETag headers, got %v", resp.Header)
+	}
+
+	for _, path := range []string{
+		"/pulumi/pulumi/releases/download/v3.78.1/pulumi-v3.78.0-linux-x64.tar.gz",
+		"/pulumi/pulumi/releases/download/v3.78.1/..%2fSHA256SUMS",
+		"/example/other/releases/download/v1.0.0/pulumi-v1.0.0-linux-x64.tar.gz",
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
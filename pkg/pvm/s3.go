@@ -0,0 +1,138 @@
+package pvm
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// S3Source reads releases from an S3-compatible bucket (AWS S3, MinIO, Ceph
+// and the like) holding archives under the same paths as GitHub release
+// downloads:
+//
+//	<prefix>/<owner>/<repo>/releases/download/v<version>/<asset>
+//
+// The bucket is addressed path-style and must allow anonymous reads.
+type S3Source struct {
+	endpoint string
+	bucket   string
+	prefix   string
+	client   *http.Client
+}
+
+// NewS3Source returns an S3Source for location, an http(s) URL of the form
+// https://<endpoint>/<bucket>[/<prefix>].
+func NewS3Source(location string, client *http.Client) (*S3Source, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 location %q: expected https://<endpoint>/<bucket>[/<prefix>]", location)
+	}
+	bucket, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 location %q: missing bucket", location)
+	}
+	return &S3Source{
+		endpoint: u.Scheme + "://" + u.Host,
+		bucket:   bucket,
+		prefix:   prefix,
+		client:   client,
+	}, nil
+}
+
+// releasesKey returns the key prefix tool's releases are stored under.
+func (s *S3Source) releasesKey(tool Tool) string {
+	return path.Join(s.prefix, tool.Repo, "releases", "download") + "/"
+}
+
+// objectURL returns the URL of an object in the bucket.
+func (s *S3Source) objectURL(key string) string {
+	return s.endpoint + "/" + s.bucket + "/" + key
+}
+
+type listBucketResult struct {
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// Describe implements DescribedSource.
+func (s *S3Source) Describe() (string, string) {
+	return "s3", strings.TrimSuffix(s.objectURL(s.prefix), "/")
+}
+
+// Releases implements ReleaseSource by listing the release "directories"
+// with ListObjectsV2. Listing by prefix does not report assets or dates.
+func (s *S3Source) Releases(ctx context.Context, tool Tool) ([]Release, error) {
+	releasesKey := s.releasesKey(tool)
+	var releases []Release
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", releasesKey)
+		query.Set("delimiter", "/")
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		data, err := fetch(ctx, s.client, s.endpoint+"/"+s.bucket+"?"+query.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bucket %s: %w", s.bucket, err)
+		}
+
+		var result listBucketResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse bucket listing: %v", err)
+		}
+
+		for _, p := range result.CommonPrefixes {
+			version := strings.TrimSuffix(strings.TrimPrefix(p.Prefix, releasesKey), "/")
+			version = strings.TrimPrefix(version, "v")
+			releases = append(releases, Release{Version: version, Prerelease: utils.IsPrerelease(version)})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	return releases, nil
+}
+
+// Latest implements ReleaseSource.
+func (s *S3Source) Latest(ctx context.Context, tool Tool) (string, error) {
+	releases, err := s.Releases(ctx, tool)
+	if err != nil {
+		return "", err
+	}
+	return latestStable(releases)
+}
+
+// AssetURL implements ReleaseSource.
+func (s *S3Source) AssetURL(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	return s.objectURL(s.releasesKey(tool) + "v" + version + "/" + tool.AssetName(version, goos, arch)), nil
+}
+
+// Checksum implements ReleaseSource using the checksums file stored with
+// the release, when there is one.
+func (s *S3Source) Checksum(ctx context.Context, tool Tool, version, goos, arch string) (string, error) {
+	name := tool.ChecksumsName(version)
+	if name == "" {
+		return "", nil
+	}
+	checksumsURL := s.objectURL(s.releasesKey(tool) + "v" + version + "/" + name)
+	return releaseChecksum(ctx, s.client, checksumsURL, tool, version, goos, arch)
+}
@@ -0,0 +1,81 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestS3Source(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases":
+			query := r.URL.Query()
+			if query.Get("list-type") != "2" || query.Get("prefix") != "mirror/pulumi/pulumi/releases/download/" || query.Get("delimiter") != "/" {
+				t.Errorf("unexpected listing query %s", r.URL.RawQuery)
+			}
+			// Serve the listing in two pages to exercise continuation.
+			if query.Get("continuation-token") == "" {
+				fmt.Fprint(w, `<ListBucketResult>
+  <CommonPrefixes><Prefix>mirror/pulumi/pulumi/releases/download/v3.78.1/</Prefix></CommonPrefixes>
+  <IsTruncated>true</IsTruncated>
+  <NextContinuationToken>page2</NextContinuationToken>
+</ListBucketResult>`)
+				return
+			}
+			fmt.Fprint(w, `<ListBucketResult>
+  <CommonPrefixes><Prefix>mirror/pulumi/pulumi/releases/download/v3.79.0-alpha.1/</Prefix></CommonPrefixes>
+  <CommonPrefixes><Prefix>mirror/pulumi/pulumi/releases/download/v3.77.0/</Prefix></CommonPrefixes>
+  <IsTruncated>false</IsTruncated>
+</ListBucketResult>`)
+		case "/releases/mirror/pulumi/pulumi/releases/download/v3.78.1/pulumi-3.78.1-checksums.txt":
+			fmt.Fprintln(w, "abcd  pulumi-v3.78.1-linux-x64.tar.gz")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewS3Source(server.URL+"/releases/mirror", server.Client())
+	if err != nil {
+		t.Fatalf("NewS3Source: %v", err)
+	}
+	ctx := context.Background()
+
+	releases, err := source.Releases(ctx, Pulumi)
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	versions := releaseVersions(releases)
+	if len(versions) != 3 || versions[0] != "3.78.1" || versions[1] != "3.79.0-alpha.1" || versions[2] != "3.77.0" {
+		t.Errorf("expected [3.78.1 3.79.0-alpha.1 3.77.0], got %v", versions)
+	}
+
+	latest, err := source.Latest(ctx, Pulumi)
+	if err != nil || latest != "3.78.1" {
+		t.Errorf("Latest = %s, %v; want 3.78.1", latest, err)
+	}
+
+	url, err := source.AssetURL(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("AssetURL: %v", err)
+	}
+	if want := server.URL + "/releases/mirror/pulumi/pulumi/releases/download/v3.78.1/pulumi-v3.78.1-linux-x64.tar.gz"; url != want {
+		t.Errorf("AssetURL = %s, want %s", url, want)
+	}
+
+	sum, err := source.Checksum(ctx, Pulumi, "3.78.1", "linux", "amd64")
+	if err != nil || sum != "abcd" {
+		t.Errorf("Checksum = %q, %v; want abcd", sum, err)
+	}
+}
+
+func TestNewS3SourceInvalidLocation(t *testing.T) {
+	for _, location := range []string{"s3://bucket", "https://minio.example.com", "not a url"} {
+		if _, err := NewS3Source(location, nil); err == nil {
+			t.Errorf("expected error for location %q, got nil", location)
+		}
+	}
+}
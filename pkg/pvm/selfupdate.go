@@ -0,0 +1,79 @@
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// Latest returns the newest stable release of the tool, from the release
+// list cached like Releases' (refresh bypasses the cache).
+func (m *Manager) Latest(ctx context.Context, refresh bool) (string, error) {
+	releases, err := m.Releases(ctx, refresh)
+	if err != nil {
+		return "", err
+	}
+	return latestStable(releases)
+}
+
+// ReplaceExecutable downloads the tool's archive of version for the
+// Manager's platform, which must hold the binary at its root, and replaces
+// the executable at path with it. The archive must match the checksum the
+// release source publishes; releases without one are refused. The new
+// binary is unpacked next to path and renamed over it, so path is never
+// left half-written. Windows does not allow replacing a running executable,
+// so there the old one is first moved aside to path.old.
+func (m *Manager) ReplaceExecutable(ctx context.Context, version, path string) error {
+	url, err := m.source.AssetURL(ctx, m.tool, version, m.goos, m.arch)
+	if err != nil {
+		return err
+	}
+	checksum, err := m.source.Checksum(ctx, m.tool, version, m.goos, m.arch)
+	if err != nil {
+		return err
+	}
+	if checksum == "" {
+		return fmt.Errorf("%s %s publishes no checksum for %s/%s; refusing to install an unverified binary", m.tool.DisplayName, version, m.goos, m.arch)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(path), "."+m.tool.Binary+"-update-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := utils.DownloadAndExtract(ctx, m.client, url, tmpDir, m.goos == "windows", 0, checksum); err != nil {
+		return fmt.Errorf("failed to download and extract: %w", err)
+	}
+
+	binary := m.tool.Binary
+	if m.goos == "windows" {
+		binary += ".exe"
+	}
+	newPath := filepath.Join(tmpDir, binary)
+	if info, err := os.Stat(newPath); err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("the %s %s archive does not contain %s", m.tool.DisplayName, version, binary)
+	}
+	if err := os.Chmod(newPath, 0755); err != nil {
+		return err
+	}
+
+	if m.goos == "windows" {
+		oldPath := path + ".old"
+		_ = os.Remove(oldPath)
+		if err := os.Rename(path, oldPath); err != nil {
+			return fmt.Errorf("failed to move %s aside: %v", path, err)
+		}
+		if err := os.Rename(newPath, path); err != nil {
+			_ = os.Rename(oldPath, path)
+			return fmt.Errorf("failed to replace %s: %v", path, err)
+		}
+		return nil
+	}
+	if err := os.Rename(newPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pvm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/tomski747/pvm/internal/config"
+)
+
+func TestReplaceExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("release archives are zip files on Windows")
+	}
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	content := "#!/bin/sh\necho v1.1.0"
+	if err := tw.WriteHeader(&tar.Header{Name: "pvm", Mode: 0755, Size: int64(len(content))}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	archive := buf.Bytes()
+	sum := sha256.Sum256(archive)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	source := &fakeSource{versions: []string{"1.1.0", "1.0.0"}, baseURL: server.URL, checksum: hex.EncodeToString(sum[:])}
+	m := New(WithRoot(t.TempDir()), WithTool(config.PVM), WithReleaseSource(source))
+	ctx := context.Background()
+
+	if latest, err := m.Latest(ctx, false); err != nil || latest != "1.1.0" {
+		t.Errorf("Latest = %q, %v; want 1.1.0", latest, err)
+	}
+
+	exe := filepath.Join(t.TempDir(), "pvm")
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := m.ReplaceExecutable(ctx, "1.1.0", exe); err != nil {
+		t.Fatalf("ReplaceExecutable: %v", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != content {
+		t.Errorf("expected the executable to be replaced, got %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("expected only the executable to be left behind, got %v", entries)
+	}
+
+	// Archives must be verified.
+	source.checksum = ""
+	if err := m.ReplaceExecutable(ctx, "1.1.0", exe); err == nil {
+		t.Error("expected an error without a checksum, got nil")
+	}
+	source.checksum = "0000"
+	if err := m.ReplaceExecutable(ctx, "1.1.0", exe); err == nil {
+		t.Error("expected an error for a checksum mismatch, got nil")
+	}
+	if data, _ := os.ReadFile(exe); string(data) != content {
+		t.Errorf("expected a failed update to leave the executable alone, got %q", data)
+	}
+}
@@ -0,0 +1,178 @@
+package pvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomski747/pvm/internal/config"
+	"github.com/tomski747/pvm/internal/utils"
+)
+
+// ReleaseSource is where a Manager discovers releases and downloads them from.
+type ReleaseSource interface {
+	// Releases returns every release of tool.
+	Releases(ctx context.Context, tool Tool) ([]Release, error)
+	// Latest returns the most recent stable version of tool.
+	Latest(ctx context.Context, tool Tool) (string, error)
+	// AssetURL returns the download URL of tool's release archive for a
+	// version and platform. Local archives use file:// URLs.
+	AssetURL(ctx context.Context, tool Tool, version, goos, arch string) (string, error)
+	// Checksum returns the hex-encoded SHA-256 of the archive AssetURL points
+	// at, or an empty string when the source does not publish checksums.
+	Checksum(ctx context.Context, tool Tool, version, goos, arch string) (string, error)
+}
+
+// ReleasePage records one page of a paginated release listing, for
+// RefreshingSource implementations.
+type ReleasePage = config.ReleasePage
+
+// RefreshingSource is implemented by release sources that can bring a
+// previously fetched release list up to date more cheaply than listing every
+// release again, e.g. with conditional requests.
+type RefreshingSource interface {
+	ReleaseSource
+	// RefreshReleases returns the current releases of tool given the
+	// releases and pages recorded by an earlier call, which are empty on the
+	// first call, along with the pages to record for the next one.
+	RefreshReleases(ctx context.Context, tool Tool, cached []Release, pages []ReleasePage) ([]Release, []ReleasePage, error)
+}
+
+// NotesSource is implemented by release sources that publish release notes.
+type NotesSource interface {
+	ReleaseSource
+	// ReleaseNotes returns the release notes of versions of tool, keyed by
+	// version. Versions without notes may be left out.
+	ReleaseNotes(ctx context.Context, tool Tool, versions []string) (map[string]string, error)
+}
+
+// DescribedSource is implemented by release sources that can say where
+// their releases come from. A Manager records the description in its
+// release cache and does not use a cache filled by a source described
+// differently.
+type DescribedSource interface {
+	ReleaseSource
+	// Describe returns the kind of the source, as accepted by
+	// NewReleaseSource, and its location.
+	Describe() (kind, location string)
+}
+
+// describeSource returns the kind and location of source. Sources not
+// implementing DescribedSource are told apart by their type.
+func describeSource(source ReleaseSource) (kind, location string) {
+	if described, ok := source.(DescribedSource); ok {
+		return described.Describe()
+	}
+	return fmt.Sprintf("%T", source), ""
+}
+
+// NewReleaseSource returns the release source of the given kind:
+//
+//   - "github" reads the GitHub API; location is unused.
+//   - "mirror" reads a release mirror such as 'pvm mirror serve' at the URL
+//     location, laid out like the GitHub API and release downloads.
+//   - "index" reads a static JSON index at location (a URL or a file path).
+//   - "dir" reads release archives from the local directory location.
+//   - "s3" reads an S3-compatible bucket at location, e.g.
+//     https://minio.example.com/bucket/prefix.
+func NewReleaseSource(kind, location string, client *http.Client) (ReleaseSource, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if kind != "github" && location == "" {
+		return nil, fmt.Errorf("release source %q requires a location", kind)
+	}
+
+	switch kind {
+	case "github":
+		return NewGitHubSource(client), nil
+	case "mirror":
+		return NewMirrorSource(location, client), nil
+	case "index":
+		return NewIndexSource(location, client), nil
+	case "dir":
+		return NewDirSource(location), nil
+	case "s3":
+		return NewS3Source(location, client)
+	default:
+		return nil, fmt.Errorf("unknown release source %q (available: github, mirror, index, dir, s3)", kind)
+	}
+}
+
+// errNotFound is returned by fetch when the requested document does not exist.
+var errNotFound = errors.New("not found")
+
+// fetch reads the document at location, which is either an http(s) URL or a
+// local file path.
+func fetch(ctx context.Context, client *http.Client, location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		data, err := os.ReadFile(strings.TrimPrefix(location, "file://"))
+		if os.IsNotExist(err) {
+			return nil, errNotFound
+		}
+		return data, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-200 response code from %s: %d", location, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// fileURL returns the file:// URL of a local path.
+func fileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return "file://" + filepath.ToSlash(path)
+}
+
+// latestStable returns the newest release that is neither a draft nor a
+// pre-release.
+func latestStable(releases []Release) (string, error) {
+	latest := ""
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		if latest == "" || utils.SemverGreater(release.Version, latest) {
+			latest = release.Version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no stable release found")
+	}
+	return latest, nil
+}
+
+// releaseChecksum fetches tool's checksums file from location and returns
+// the checksum of the asset for a version and platform. Versions without a
+// checksums file have no checksum.
+func releaseChecksum(ctx context.Context, client *http.Client, location string, tool Tool, version, goos, arch string) (string, error) {
+	data, err := fetch(ctx, client, location)
+	if errors.Is(err, errNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	return utils.ParseChecksums(data, tool.AssetName(version, goos, arch)), nil
+}
@@ -0,0 +1,37 @@
+package pvm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewReleaseSource(t *testing.T) {
+	tests := []struct {
+		kind     string
+		location string
+		want     string
+	}{
+		{"github", "", "*pvm.GitHubSource"},
+		{"mirror", "http://mirror.local:8080", "*pvm.GitHubSource"},
+		{"index", "https://example.com/index.json", "*pvm.IndexSource"},
+		{"dir", "/srv/pulumi", "*pvm.DirSource"},
+		{"s3", "https://minio.example.com/releases", "*pvm.S3Source"},
+	}
+	for _, tc := range tests {
+		source, err := NewReleaseSource(tc.kind, tc.location, nil)
+		if err != nil {
+			t.Errorf("NewReleaseSource(%q): %v", tc.kind, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", source); got != tc.want {
+			t.Errorf("NewReleaseSource(%q) = %s, want %s", tc.kind, got, tc.want)
+		}
+	}
+
+	if _, err := NewReleaseSource("dir", "", nil); err == nil {
+		t.Error("expected error for a dir source without a location, got nil")
+	}
+	if _, err := NewReleaseSource("ftp", "ftp://example.com", nil); err == nil {
+		t.Error("expected error for an unknown source, got nil")
+	}
+}